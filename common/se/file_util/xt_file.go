@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 // GetProjectAbsPath returns the project absolute path
@@ -20,6 +21,18 @@ func GetCurrentFileAbsPath() (string, bool) {
 	return file, ok
 }
 
+// GetExecutableDirPath returns the absolute path of the directory containing the executable, the symlinks are resolved
+func GetExecutableDirPath() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", err
+	}
+	return filepath.Dir(executable), nil
+}
+
 type File struct {
 	Path string // package path
 }
@@ -43,6 +56,31 @@ func (_this *File) GetDirPath() (string, error) {
 	return filepath.Abs(_this.Path)
 }
 
+// Exists returns whether the file or directory exists
+func (_this *File) Exists() bool {
+	_, err := os.Stat(_this.Path)
+	return err == nil
+}
+
+// Stat returns the os.FileInfo of the file
+func (_this *File) Stat() (os.FileInfo, error) {
+	return os.Stat(_this.Path)
+}
+
+// ModTime returns the modification time of the file
+func (_this *File) ModTime() (time.Time, error) {
+	fileInfo, err := os.Stat(_this.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fileInfo.ModTime(), nil
+}
+
+// ReadAll returns the content of the file
+func (_this *File) ReadAll() ([]byte, error) {
+	return os.ReadFile(_this.Path)
+}
+
 // IsFile returns check whether the file is a file
 func (_this *File) IsFile() (bool, error) {
 	dir, err := _this.IsDir()
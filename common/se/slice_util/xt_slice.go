@@ -17,7 +17,7 @@ func FindString(slice []string, s string) int {
 
 // RemoveString removes a string from a slice.
 func RemoveString(slice []string, s string) []string {
-	var newSlice []string
+	newSlice := make([]string, len(slice))
 	copy(newSlice, slice)
 	for i := 0; i < len(newSlice); i++ {
 		if newSlice[i] == s {
@@ -32,7 +32,7 @@ func RemoveString(slice []string, s string) []string {
 
 // RemoveFirstString removes first string from a slice. O(n)
 func RemoveFirstString(slice []string, s string) []string {
-	var newSlice []string
+	newSlice := make([]string, len(slice))
 	copy(newSlice, slice)
 	for i := 0; i < len(newSlice); i++ {
 		if newSlice[i] == s {
@@ -5,7 +5,7 @@ package core
 
 // Context - Global context variable, you can rebuild it yourself. you can also implement your own Container in it
 // 全局上下文变量，你可以自行重建该变量,  你也可以实现自己的 Container 替换里面的 BeanContainer
-var Context = NewBeanFactory()
+var Context = NewStandardBeanFactory()
 
 func AppContext() *BeanFactory {
 	return Context
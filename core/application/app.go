@@ -1,10 +1,12 @@
 package application
 
 import (
+	"context"
 	"github.com/cutexingluo/go-spring/core"
 	"github.com/cutexingluo/go-spring/core/frame"
 	"github.com/cutexingluo/go-spring/core/parse"
 	"reflect"
+	"time"
 )
 
 // AutoConfig -   add tag-func 	parse.Initialize and parse.BeanInject  to  core.Context.BeanChains  自动配置
@@ -14,51 +16,76 @@ func SetAutoConfig(flag bool) {
 	AutoConfig = flag
 }
 
+// ConfigAutoLoad - load the config files (application.yaml, application.properties ...) into core.Context.Environment()
+// before BeanCreated, see config.LoadDir. 自动加载配置文件
+var ConfigAutoLoad = true
+
+// ConfigDir - the dir of the config files, empty is the dir of the executable. 配置文件目录
+var ConfigDir = ""
+
+// ConfigWatchInterval - the polling interval of the config files, 0 is no watch, see WithConfigWatch. 配置文件轮询间隔
+var ConfigWatchInterval time.Duration = 0
+
+// SetConfigWatchInterval set the polling interval of the config files, see ConfigWatchInterval
+func SetConfigWatchInterval(interval time.Duration) {
+	ConfigWatchInterval = interval
+}
+
+// ConfigWatchErrorHandler - handle the errors of the config watcher, such as the syntax errors of the reloaded files,
+// nil ignores them, see WithConfigWatchErrorHandler. 配置文件监视错误处理
+var ConfigWatchErrorHandler func(err error)
+
+// SetConfigWatchErrorHandler set the handler of the config watcher errors, see ConfigWatchErrorHandler
+func SetConfigWatchErrorHandler(handler func(err error)) {
+	ConfigWatchErrorHandler = handler
+}
+
+// SetConfigAutoLoad whether to load the config files, see ConfigAutoLoad
+func SetConfigAutoLoad(flag bool) {
+	ConfigAutoLoad = flag
+}
+
+// SetConfigDir set the dir of the config files, see ConfigDir
+func SetConfigDir(dir string) {
+	ConfigDir = dir
+}
+
 // Run starts the application. It will load the file at filePath and start the application.
+// the context-aware handlers get core.Context.RootContext(), which is cancelled on shutdown.
+// use New to run an application with its own BeanFactory.
 // 主程序入口， 启动应用
 func Run(lifeCycle ...interface{}) (err error) {
+	return defaultApplication().Run(lifeCycle...)
+}
+
+// RunContext starts the application like Run, ctx is passed to the context-aware handlers, such as
+// core.AfterInitializationCtxHandler and core.OnMainCtxHandler. the startup stops with ctx.Err() if the ctx is done.
+// 带上下文启动应用, 可以通过 ctx 限制启动时间或取消
+func RunContext(ctx context.Context, lifeCycle ...interface{}) (err error) {
+	return defaultApplication().RunContext(ctx, lifeCycle...)
+}
+
+// SetParallelism set the max workers to initialize the beans of a topo layer and run AfterInitialization concurrently,
+// 0 or 1 is serial. 设置并行初始化的最大协程数
+func SetParallelism(workers int) {
+	core.Context.Parallelism = workers
+}
+
+// SetProfiles set the active profiles of core.Context, it overrides the environment variable GO_SPRING_PROFILES_ACTIVE.
+// it returns ErrContainerFrozen after core.Context is frozen. 设置激活的 profile
+func SetProfiles(profiles ...string) error {
+	return core.Context.SetActiveProfiles(profiles...)
+}
+
+// Close stop the config watcher of the default application, then destroy all beans of core.Context in the reverse
+// order of the dependencies, see core.BeanFactory.Close. 销毁所有 bean
+func Close() error {
+	return DefaultApplication().Close()
+}
 
-	if AutoConfig {
-		frame.InitBeanFunc() // add func tag to  core.Context.BeanChains
-	}
-
-	// BeanCreated
-	if err = core.BeanLifeCycleExecute(lifeCycle, func(lifeCycle any) error {
-		return core.CreateHandlerExecute(lifeCycle)
-	}); err != nil {
-		return
-	}
-	if err = core.BeanCreatedFunc(); err != nil {
-		return
-	}
-
-	// TagInitialized -> AfterInstantiation
-	if err = core.TagInitializedFunc(); err != nil {
-		return
-	}
-	if err = core.BeanLifeCycleExecute(lifeCycle, func(lifeCycle any) error {
-		return core.AfterInstantiationHandlerExecute(lifeCycle)
-	}); err != nil {
-		return
-	}
-
-	// BeanInjected -> AfterInitialization
-	if err = core.BeanInjectedFunc(); err != nil {
-		return
-	}
-	if err = core.BeanLifeCycleExecute(lifeCycle, func(lifeCycle any) error {
-		return core.AfterInitializationHandlerExecute(lifeCycle)
-	}); err != nil {
-		return
-	}
-
-	// ChooseMainHandler
-	if err = core.BeanLifeCycleExecute(lifeCycle, func(lifeCycle any) error {
-		return core.ChooseMainHandlerExecute(lifeCycle)
-	}); err != nil {
-		return
-	}
-	return
+// SetDestroyTimeout set the max time to destroy a bean in Close, 0 is no timeout. 设置销毁单个 bean 的超时时间
+func SetDestroyTimeout(timeout time.Duration) {
+	core.Context.DestroyTimeout = timeout
 }
 
 // AddBeanFilterFunc you can add bean filter function here.
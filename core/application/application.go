@@ -0,0 +1,296 @@
+package application
+
+import (
+	"context"
+	"github.com/cutexingluo/go-spring/common/se/file_util"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/bean_init"
+	"github.com/cutexingluo/go-spring/core/config"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"sync"
+	"time"
+)
+
+// Application an application with its own BeanFactory, so several applications can run in one process.
+// the package functions Run, RunContext and RunAndWait use the application of core.Context.
+// 拥有独立 BeanFactory 的应用
+type Application struct {
+	factory         *core.BeanFactory
+	autoConfig      bool          // add the tag functions to the BeanChains of the factory
+	shutdownTimeout time.Duration // the deadline of the shutdown in RunAndWait
+	configAutoLoad  bool          // load the config files before BeanCreated
+	configDir       string        // the dir of the config files, empty is the dir of the executable
+	configWatch     time.Duration // the polling interval of the config files, 0 is no watch
+	watchError      func(error)   // handle the errors of the watcher
+	watcher         *config.Watcher
+	factoryOptions  []func(factory *core.BeanFactory) error // the settings of the factory, applied after all options
+	err             error                                   // the error of the settings, returned by RunContext
+}
+
+// Option the option of New
+type Option func(app *Application)
+
+// WithFactory use the factory instead of a new one, such as core.Context
+func WithFactory(factory *core.BeanFactory) Option {
+	return func(app *Application) {
+		app.factory = factory
+	}
+}
+
+// WithAutoConfig whether to add the tag functions to the BeanChains of the factory, default true
+func WithAutoConfig(flag bool) Option {
+	return func(app *Application) {
+		app.autoConfig = flag
+	}
+}
+
+// WithParallelism set the max workers to initialize the beans, see SetParallelism
+func WithParallelism(workers int) Option {
+	return withFactoryOption(func(factory *core.BeanFactory) error {
+		factory.Parallelism = workers
+		return nil
+	})
+}
+
+// WithDestroyTimeout set the max time to destroy a bean in Close, see SetDestroyTimeout
+func WithDestroyTimeout(timeout time.Duration) Option {
+	return withFactoryOption(func(factory *core.BeanFactory) error {
+		factory.DestroyTimeout = timeout
+		return nil
+	})
+}
+
+// WithProfiles set the active profiles of the factory, it overrides the environment variable GO_SPRING_PROFILES_ACTIVE.
+// see core.BeanConfig.Profiles
+func WithProfiles(profiles ...string) Option {
+	return withFactoryOption(func(factory *core.BeanFactory) error {
+		return factory.SetActiveProfiles(profiles...)
+	})
+}
+
+// WithPropertySources add the property sources to the Environment of the factory in order, after the command-line
+// arguments and the environment variables, so they can be overridden by them. see core.Environment
+func WithPropertySources(sources ...core.PropertySource) Option {
+	return withFactoryOption(func(factory *core.BeanFactory) error {
+		for _, source := range sources {
+			factory.Environment().AddLast(source)
+		}
+		return nil
+	})
+}
+
+// withFactoryOption keep the setting of the factory, it is applied to the factory of the application after all options,
+// so it does not matter whether WithFactory comes first. the first error is returned by RunContext
+func withFactoryOption(setting func(factory *core.BeanFactory) error) Option {
+	return func(app *Application) {
+		app.factoryOptions = append(app.factoryOptions, setting)
+	}
+}
+
+// WithConfigAutoLoad whether to load the config files before BeanCreated, default true. see config.LoadDir
+func WithConfigAutoLoad(flag bool) Option {
+	return func(app *Application) {
+		app.configAutoLoad = flag
+	}
+}
+
+// WithConfigDir set the dir of the config files, default the dir of the executable
+func WithConfigDir(dir string) Option {
+	return func(app *Application) {
+		app.configDir = dir
+	}
+}
+
+// WithConfigWatch poll the config files by the interval after AfterInitialization, 0 is no watch. when they change, the
+// fields tagged `refresh:"true"` are re-initialized and the config.ConfigChangedListener beans are notified.
+// see config.Watcher
+func WithConfigWatch(interval time.Duration) Option {
+	return func(app *Application) {
+		app.configWatch = interval
+	}
+}
+
+// WithConfigWatchErrorHandler handle the errors of the config watcher, such as the syntax errors of the reloaded files
+func WithConfigWatchErrorHandler(handler func(err error)) Option {
+	return func(app *Application) {
+		app.watchError = handler
+	}
+}
+
+// WithShutdownTimeout set the deadline of the shutdown in RunAndWait, see SetShutdownTimeout
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(app *Application) {
+		app.shutdownTimeout = timeout
+	}
+}
+
+// New create an application with a new BeanFactory, the options are applied in order, then the settings of the factory
+// (such as WithParallelism and WithProfiles) are applied to the final factory, their first error (such as
+// ErrContainerFrozen of a frozen factory) is returned by Run. 创建拥有独立 BeanFactory 的应用
+func New(opts ...Option) *Application {
+	app := &Application{
+		factory:         core.NewStandardBeanFactory(),
+		autoConfig:      true,
+		shutdownTimeout: 30 * time.Second,
+		configAutoLoad:  true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(app)
+		}
+	}
+	for _, setting := range app.factoryOptions {
+		if err := setting(app.factory); err != nil && app.err == nil {
+			app.err = err
+		}
+	}
+	return app
+}
+
+var (
+	defaultLock sync.Mutex
+	defaultApp  *Application // the application of core.Context, kept for Close and the config watcher
+)
+
+// DefaultApplication get the application of core.Context used by the package functions, such as Run and Close, so its
+// config watcher can be got and stopped. it is created again if core.Context is replaced. 默认应用
+func DefaultApplication() *Application {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultApp == nil || defaultApp.factory != core.Context {
+		defaultApp = &Application{factory: core.Context}
+		defaultApp.loadDefaultSettings()
+	}
+	return defaultApp
+}
+
+// defaultApplication the default application with the package variables at present, it is used by Run
+func defaultApplication() *Application {
+	app := DefaultApplication()
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	app.loadDefaultSettings()
+	return app
+}
+
+// loadDefaultSettings set the settings by the package variables
+func (_this *Application) loadDefaultSettings() {
+	_this.autoConfig = AutoConfig
+	_this.shutdownTimeout = ShutdownTimeout
+	_this.configAutoLoad = ConfigAutoLoad
+	_this.configDir = ConfigDir
+	_this.configWatch = ConfigWatchInterval
+	_this.watchError = ConfigWatchErrorHandler
+}
+
+// Factory get the BeanFactory of the application
+func (_this *Application) Factory() *core.BeanFactory {
+	return _this.factory
+}
+
+// ConfigWatcher get the watcher of the config files, nil if the config files are not watched
+func (_this *Application) ConfigWatcher() *config.Watcher {
+	return _this.watcher
+}
+
+// Run starts the application, the context-aware handlers get the RootContext of the factory. see application.Run
+func (_this *Application) Run(lifeCycle ...interface{}) (err error) {
+	return _this.RunContext(_this.factory.RootContext(), lifeCycle...)
+}
+
+// RunContext starts the application with ctx. see application.RunContext
+func (_this *Application) RunContext(ctx context.Context, lifeCycle ...interface{}) (err error) {
+	if _this.err != nil {
+		return _this.err
+	}
+	factory := _this.factory
+	if _this.autoConfig {
+		frame.InitFactoryBeanFunc(factory) // add func tag to  factory.BeanChains
+	}
+	if err = _this.loadConfig(); err != nil {
+		return
+	}
+
+	// BeanCreated
+	if err = core.BeanLifeCycleExecuteCtx(ctx, lifeCycle, func(ctx context.Context, lifeCycle any) error {
+		return factory.CreateHandlerExecute(lifeCycle)
+	}); err != nil {
+		return
+	}
+	if err = factory.RunBeanCreatedCtx(ctx); err != nil {
+		return
+	}
+
+	// TagInitialized -> AfterInstantiation
+	if err = factory.RunTagInitializedCtx(ctx); err != nil {
+		return
+	}
+	if err = core.BeanLifeCycleExecuteCtx(ctx, lifeCycle, factory.AfterInstantiationHandlerExecuteCtx); err != nil {
+		return
+	}
+
+	// BeanInjected -> AfterInitialization
+	if err = factory.RunBeanInjectedCtx(ctx); err != nil {
+		return
+	}
+	if factory.Parallelism > 1 {
+		err = core.BeanLifeCycleExecuteParallelCtx(ctx, lifeCycle, factory.Parallelism, factory.AfterInitializationHandlerExecuteCtx)
+	} else {
+		err = core.BeanLifeCycleExecuteCtx(ctx, lifeCycle, factory.AfterInitializationHandlerExecuteCtx)
+	}
+	if err != nil {
+		return
+	}
+	if _this.watcher != nil {
+		_this.watcher.Start()
+	}
+
+	// ChooseMainHandler
+	if err = core.BeanLifeCycleExecuteCtx(ctx, lifeCycle, factory.ChooseMainHandlerExecuteCtx); err != nil {
+		return
+	}
+	return
+}
+
+// loadConfig load the config files into the Environment of the factory, the active profiles select the profile files.
+// the watcher is created if the config files are watched
+func (_this *Application) loadConfig() (err error) {
+	if !_this.configAutoLoad {
+		return nil
+	}
+	dir := _this.configDir
+	if dir == "" {
+		if dir, err = file_util.GetExecutableDirPath(); err != nil {
+			return err
+		}
+	}
+	if err = config.AddDir(_this.factory, dir); err != nil {
+		return err
+	}
+	if _this.configWatch > 0 && _this.watcher == nil {
+		_this.watcher = config.NewWatcher(_this.factory, dir)
+		_this.watcher.Interval = _this.configWatch
+		_this.watcher.Refresh = bean_init.RefreshWith
+		_this.watcher.ErrorHandler = _this.watchError
+	}
+	return nil
+}
+
+// Close stop the config watcher, then destroy all beans of the factory in the reverse order of the dependencies,
+// see core.BeanFactory.Close
+func (_this *Application) Close() error {
+	_this.stopWatcher()
+	return _this.factory.Close()
+}
+
+// stopWatcher stop the config watcher if the config files are watched
+func (_this *Application) stopWatcher() {
+	if _this.watcher != nil {
+		_this.watcher.Stop()
+	}
+}
+
+// AddBeanFilterFunc add the bean filter function to the factory of the application, see application.AddBeanFilterFunc
+func (_this *Application) AddBeanFilterFunc(executionTime int, beanFilterFunc *core.BeanFilterFunction) {
+	frame.AddFactoryBeanFilterFunc(_this.factory, executionTime, beanFilterFunc)
+}
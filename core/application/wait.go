@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// the exit codes returned by RunAndWait
+const (
+	ExitOK    = 0 // started and shut down gracefully
+	ExitError = 1 // failed to start, or failed to shut down in the ShutdownTimeout
+)
+
+// ShutdownTimeout - the deadline of the shutdown in RunAndWait, including waiting for the main goroutines and destroying
+// the beans, 0 is no deadline. 关闭的超时时间
+var ShutdownTimeout = 30 * time.Second
+
+func SetShutdownTimeout(timeout time.Duration) {
+	ShutdownTimeout = timeout
+}
+
+// RunAndWait starts the application like Run, then waits until all main goroutines (such as the useGo func of
+// OnMainHandler) return, SIGINT / SIGTERM is received or core.Context.Shutdown is called. then it cancels core.Context.RootContext(), waits for the
+// main goroutines and destroys the beans in the ShutdownTimeout. the errors are written to stderr.
+// you can use it like os.Exit(application.RunAndWait(...)).
+// 启动应用并等待, 收到退出信号后优雅关闭, 返回退出码
+func RunAndWait(lifeCycle ...interface{}) int {
+	return defaultApplication().RunAndWait(lifeCycle...)
+}
+
+// RunAndWait starts the application and waits for the shutdown, see application.RunAndWait
+func (_this *Application) RunAndWait(lifeCycle ...interface{}) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := _this.Run(lifeCycle...); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "failed to run the application:", err)
+		_this.factory.Shutdown()
+		_ = _this.Close()
+		return ExitError
+	}
+	select {
+	case <-_this.factory.MainDone():
+	case <-_this.factory.RootContext().Done(): // core.BeanFactory.Shutdown
+	case <-signals:
+	}
+	return _this.shutdown()
+}
+
+// shutdown cancel the root context and stop the config watcher, wait for the main goroutines in the shutdownTimeout,
+// then close the factory in the rest of it. if the main goroutines use up the shutdownTimeout, the beans are still
+// destroyed, each of them in the DestroyTimeout of the factory.
+func (_this *Application) shutdown() int {
+	_this.factory.Shutdown()
+	_this.stopWatcher()
+	ctx := context.Background()
+	if _this.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, _this.shutdownTimeout)
+		defer cancel()
+	}
+	code := ExitOK
+	select {
+	case <-_this.factory.MainDone():
+	case <-ctx.Done():
+		_, _ = fmt.Fprintf(os.Stderr, "failed to stop the main goroutines in %s\n", _this.shutdownTimeout)
+		ctx, code = context.Background(), ExitError
+	}
+	if err := _this.factory.CloseContext(ctx); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "failed to shut down the application:", err)
+		code = ExitError
+	}
+	return code
+}
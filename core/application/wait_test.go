@@ -0,0 +1,128 @@
+package application_test
+
+import (
+	"context"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/application"
+	"testing"
+	"time"
+)
+
+// waitResource the bean destroyed on shutdown
+type waitResource struct {
+	destroyed int
+}
+
+func (r *waitResource) Destroy() error {
+	r.destroyed++
+	return nil
+}
+
+// waitLifeCycle starts a main goroutine, it returns when the ctx is done, or when release is closed if ignoreCtx
+type waitLifeCycle struct {
+	resource  *waitResource
+	started   chan struct{}
+	release   chan struct{}
+	ignoreCtx bool
+}
+
+func newWaitLifeCycle(ignoreCtx bool) *waitLifeCycle {
+	return &waitLifeCycle{
+		resource:  &waitResource{},
+		started:   make(chan struct{}),
+		release:   make(chan struct{}),
+		ignoreCtx: ignoreCtx,
+	}
+}
+
+func (l *waitLifeCycle) Create() *core.BeanConfig {
+	return &core.BeanConfig{SingleBeans: []any{l.resource}}
+}
+
+func (l *waitLifeCycle) OnMainCtx(ctx context.Context, factory *core.BeanFactory) (func(ctx context.Context), bool, error) {
+	return func(ctx context.Context) {
+		close(l.started)
+		if l.ignoreCtx {
+			<-l.release
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case <-l.release:
+		}
+	}, false, nil
+}
+
+// runAndWait run the application in a goroutine, returns the channel of the exit code after the main goroutine starts
+func runAndWait(t *testing.T, app *application.Application, lifeCycle *waitLifeCycle) <-chan int {
+	t.Helper()
+	code := make(chan int, 1)
+	go func() {
+		code <- app.RunAndWait(lifeCycle)
+	}()
+	select {
+	case <-lifeCycle.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the main goroutine is not started")
+	}
+	return code
+}
+
+// waitCode wait for the exit code of RunAndWait
+func waitCode(t *testing.T, code <-chan int) int {
+	t.Helper()
+	select {
+	case c := <-code:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAndWait does not return")
+		return -1
+	}
+}
+
+// TestRunAndWaitShutdown Shutdown cancels the ctx of the main goroutine, then the beans are destroyed once
+func TestRunAndWaitShutdown(t *testing.T) {
+	app := application.New(application.WithConfigAutoLoad(false))
+	lifeCycle := newWaitLifeCycle(false)
+	code := runAndWait(t, app, lifeCycle)
+	app.Factory().Shutdown()
+	if c := waitCode(t, code); c != application.ExitOK {
+		t.Errorf("got the exit code %d, want ExitOK", c)
+	}
+	if err := app.Close(); err != nil {
+		t.Errorf("the second close got the error %v", err)
+	}
+	if lifeCycle.resource.destroyed != 1 || !app.Factory().IsClosed() {
+		t.Errorf("the bean is destroyed %d times, want once", lifeCycle.resource.destroyed)
+	}
+}
+
+// TestRunAndWaitMainDone RunAndWait returns when the main goroutines return by themselves
+func TestRunAndWaitMainDone(t *testing.T) {
+	app := application.New(application.WithConfigAutoLoad(false))
+	lifeCycle := newWaitLifeCycle(false)
+	code := runAndWait(t, app, lifeCycle)
+	close(lifeCycle.release)
+	if c := waitCode(t, code); c != application.ExitOK {
+		t.Errorf("got the exit code %d, want ExitOK", c)
+	}
+	if lifeCycle.resource.destroyed != 1 {
+		t.Errorf("the bean is destroyed %d times, want once", lifeCycle.resource.destroyed)
+	}
+}
+
+// TestRunAndWaitShutdownTimeout the main goroutine ignoring the ctx exceeds the ShutdownTimeout, the beans are still
+// destroyed
+func TestRunAndWaitShutdownTimeout(t *testing.T) {
+	app := application.New(application.WithConfigAutoLoad(false), application.WithShutdownTimeout(20*time.Millisecond))
+	lifeCycle := newWaitLifeCycle(true)
+	defer close(lifeCycle.release)
+	code := runAndWait(t, app, lifeCycle)
+	app.Factory().Shutdown()
+	if c := waitCode(t, code); c != application.ExitError {
+		t.Errorf("got the exit code %d, want ExitError", c)
+	}
+	if lifeCycle.resource.destroyed != 1 {
+		t.Errorf("the bean is destroyed %d times after the timeout, want once", lifeCycle.resource.destroyed)
+	}
+}
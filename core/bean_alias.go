@@ -0,0 +1,74 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RegisterAlias register an alias of the bean, the alias can be used instead of the bean name or the bean type in
+// HasBean, GetBean and the bean tag `bean:"alias"`. the alias can refer to another alias, but they can not form a cycle,
+// and it can not be the name of a bean. 注册 bean 的别名
+func (_this *BeanFactory) RegisterAlias(alias string, beanNameOrType string) error {
+	alias = BeanNameFilter(alias)
+	beanNameOrType = BeanNameFilter(beanNameOrType)
+	if alias == "" || beanNameOrType == "" {
+		return fmt.Errorf("the alias and the bean name can not be empty")
+	}
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "RegisterAlias", BeanName: alias}
+	}
+	if alias == beanNameOrType {
+		return fmt.Errorf("the alias '%s' can not refer to itself", alias)
+	}
+	if _this.BeanContainer.HasBean(alias) || _this.getScopedDefinition(alias) != nil {
+		return fmt.Errorf("the alias '%s' is the name of a bean", alias)
+	}
+	if registered, ok := _this.aliases[alias]; ok {
+		if registered == beanNameOrType {
+			return nil
+		}
+		return fmt.Errorf("the alias '%s' is already registered for the bean '%s'", alias, registered)
+	}
+	path := []string{alias, beanNameOrType}
+	for name, ok := _this.aliases[beanNameOrType]; ok; name, ok = _this.aliases[name] {
+		path = append(path, name)
+		if name == alias {
+			return fmt.Errorf("the alias '%s' forms a cycle: %s", alias, strings.Join(path, " -> "))
+		}
+	}
+	_this.aliases[alias] = beanNameOrType
+	return nil
+}
+
+// CanonicalName get the bean name of the alias, if it is not an alias, the name is returned.
+// the aliases of the parent factory are resolved if the bean is not in this factory. 获取别名对应的 bean 名称
+func (_this *BeanFactory) CanonicalName(beanNameOrAlias string) string {
+	name := BeanNameFilter(beanNameOrAlias)
+	for target, ok := _this.aliases[name]; ok; target, ok = _this.aliases[name] {
+		name = target
+	}
+	if _this.Parent != nil && !_this.BeanContainer.HasBean(name) && _this.getScopedDefinition(name) == nil {
+		return _this.Parent.CanonicalName(name)
+	}
+	return name
+}
+
+// IsAlias checks whether the name is an alias of this factory
+func (_this *BeanFactory) IsAlias(name string) bool {
+	_, ok := _this.aliases[BeanNameFilter(name)]
+	return ok
+}
+
+// GetAliases get the aliases of the bean in this factory, sorted by name. 获取 bean 的所有别名
+func (_this *BeanFactory) GetAliases(beanName string) []string {
+	beanName = _this.CanonicalName(beanName)
+	var aliases []string
+	for alias := range _this.aliases {
+		if _this.CanonicalName(alias) == beanName {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
@@ -0,0 +1,110 @@
+package core_test
+
+import (
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type aliasDB struct{}
+
+type aliasRepo struct {
+	DB *aliasDB `bean:"database"`
+}
+
+func TestRegisterAlias(t *testing.T) {
+	factory := core.NewBeanFactory()
+	if _, err := factory.AddMultiBean("mainDB", &aliasDB{}); err != nil {
+		t.Fatal(err)
+	}
+	for alias, target := range map[string]string{"db": "mainDB", "database": "db", "store": "database"} {
+		if err := factory.RegisterAlias(alias, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := factory.RegisterAlias("db", "mainDB"); err != nil {
+		t.Errorf("registering the same alias again got the error %v", err)
+	}
+
+	tests := []struct {
+		alias   string
+		target  string
+		message string
+	}{
+		{"", "mainDB", "can not be empty"},
+		{"self", "self", "refer to itself"},
+		{"mainDB", "db", "is the name of a bean"},
+		{"db", "other", "already registered"},
+	}
+	for _, test := range tests {
+		if err := factory.RegisterAlias(test.alias, test.target); err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("RegisterAlias(%q, %q) got the error %v, want %q", test.alias, test.target, err, test.message)
+		}
+	}
+
+	for _, name := range []string{"mainDB", "db", "database", "store", "*store"} {
+		if got := factory.CanonicalName(name); got != "mainDB" {
+			t.Errorf("CanonicalName(%q) got %q, want mainDB", name, got)
+		}
+	}
+	if got := factory.CanonicalName("unknown"); got != "unknown" {
+		t.Errorf("CanonicalName of the name without aliases got %q", got)
+	}
+	if got := factory.GetAliases("database"); !reflect.DeepEqual(got, []string{"database", "db", "store"}) {
+		t.Errorf("got the aliases %v", got)
+	}
+	value, err := factory.GetBeanValue("store")
+	if err != nil || value == nil || value.Interface() != core.MustGet[*aliasDB](factory) {
+		t.Errorf("GetBeanValue by the alias got %v and the error %v", value, err)
+	}
+	if !factory.HasBean("store") || !factory.IsAlias("store") || factory.IsAlias("mainDB") {
+		t.Error("the alias is not found")
+	}
+}
+
+// TestRegisterAliasCycle the aliases can not form a cycle, even without the bean
+func TestRegisterAliasCycle(t *testing.T) {
+	factory := core.NewBeanFactory()
+	for alias, target := range map[string]string{"a": "b", "b": "c"} {
+		if err := factory.RegisterAlias(alias, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := factory.RegisterAlias("c", "a")
+	if err == nil || !strings.Contains(err.Error(), "c -> a -> b -> c") {
+		t.Errorf("got the error %v, want the cycle c -> a -> b -> c", err)
+	}
+	if factory.IsAlias("c") {
+		t.Error("the alias of the cycle is registered")
+	}
+}
+
+// TestAliasInjection the bean tag can use the alias, and the child factory resolves the aliases of the parent
+func TestAliasInjection(t *testing.T) {
+	parent := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(parent)
+	if err := parent.AddBeanConfig(&core.BeanConfig{SingleBeans: []any{&aliasDB{}, &aliasRepo{}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parent.RegisterAlias("database", "core_test.aliasDB"); err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, parent)
+	db := core.MustGet[*aliasDB](parent)
+	if repo := core.MustGet[*aliasRepo](parent); repo.DB != db {
+		t.Error("the bean is not injected by the alias")
+	}
+	if err := parent.RegisterAlias("late", "core_test.aliasDB"); err == nil {
+		t.Error("the alias is registered after Freeze")
+	}
+
+	child := core.NewChildBeanFactory(parent)
+	if got := child.CanonicalName("database"); got != "core_test.aliasDB" {
+		t.Errorf("the child got %q, want the bean of the parent alias", got)
+	}
+	if bean, err := child.GetBean("database"); err != nil || bean != db {
+		t.Errorf("the child got %v and the error %v by the parent alias", bean, err)
+	}
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ProfilesEnv the environment variable of the active profiles, separated by comma, such as GO_SPRING_PROFILES_ACTIVE=dev,test.
+// it is used if the active profiles are not set by SetActiveProfiles. 激活 profile 的环境变量
+const ProfilesEnv = "GO_SPRING_PROFILES_ACTIVE"
+
+// Condition the condition of a BeanConfig or a bean, the beans are registered only if it matches. 注册 bean 的条件
+type Condition interface {
+	// Matches - whether the beans can be registered, it is evaluated before BeanCreated
+	Matches(factory *BeanFactory) bool
+}
+
+// ConditionFunc the function implements Condition
+type ConditionFunc func(factory *BeanFactory) bool
+
+// Matches call the function
+func (f ConditionFunc) Matches(factory *BeanFactory) bool {
+	return f(factory)
+}
+
+// PropertyResolver resolve the property by key, it is used by OnProperty. 属性解析器
+type PropertyResolver interface {
+	// GetProperty - get the property, ok is false if it is not found
+	GetProperty(key string) (value string, ok bool)
+}
+
+// ConditionalBean the bean registered only if all conditions match, it can be used in the SingleBeans, MultiBeans and
+// Providers of BeanConfig, see Conditional. 条件 bean
+type ConditionalBean struct {
+	Bean       any         // the bean, or the provider in Providers
+	Conditions []Condition // all of them must match
+}
+
+// Conditional wrap the bean with the conditions, such as
+// SingleBeans: []any{core.Conditional(&MockDB{}, core.OnMissingBean("pkg.DB"))}. 条件注册 bean
+func Conditional(bean any, conditions ...Condition) *ConditionalBean {
+	return &ConditionalBean{Bean: bean, Conditions: conditions}
+}
+
+// OnBean matches if the bean is in the factory, or it will be added by a provider. beanNameOrType can be the bean name
+// or type string, or a reflect.Type, the reflect.Type of an interface matches the beans implementing it. 存在 bean 时匹配
+func OnBean(beanNameOrType any) Condition {
+	return ConditionFunc(func(factory *BeanFactory) bool {
+		return hasConditionBean(factory, beanNameOrType)
+	})
+}
+
+// OnMissingBean matches if the bean is not in the factory and no provider adds it, the argument is like OnBean, so a
+// default bean can be replaced by a provider. 不存在 bean 时匹配
+func OnMissingBean(beanNameOrType any) Condition {
+	return ConditionFunc(func(factory *BeanFactory) bool {
+		return !hasConditionBean(factory, beanNameOrType)
+	})
+}
+
+// OnProperty matches if the property equals the value, if the value is empty, it matches if the property exists and
+// is not "false". the property is resolved by the PropertyResolver of the factory, or the Environment of the factory if
+// it is not set. 属性匹配时匹配
+func OnProperty(key string, value string) Condition {
+	return ConditionFunc(func(factory *BeanFactory) bool {
+		property, ok := factory.resolveProperty(key)
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return !strings.EqualFold(strings.TrimSpace(property), "false")
+		}
+		return strings.TrimSpace(property) == value
+	})
+}
+
+// OnProfile matches if the profiles match, like BeanConfig.Profiles. profile 匹配时匹配
+func OnProfile(profiles ...string) Condition {
+	return ConditionFunc(func(factory *BeanFactory) bool {
+		return factory.AcceptsProfiles(profiles...)
+	})
+}
+
+// hasConditionBean checks the bean by the name, type string or reflect.Type, the beans of the providers not invoked yet
+// are included
+func hasConditionBean(factory *BeanFactory, beanNameOrType any) bool {
+	switch target := beanNameOrType.(type) {
+	case string:
+		return factory.HasBean(target) || factory.hasProvidedBean(func(p *beanProvider) bool {
+			return p.beanType == BeanNameFilter(target)
+		})
+	case reflect.Type:
+		return len(factory.GetBeanNamesByType(target)) > 0 || factory.hasProvidedBean(func(p *beanProvider) bool {
+			return p.provides(target)
+		})
+	default:
+		return false
+	}
+}
+
+// hasProvidedBean checks whether a provider not invoked yet matches, in the factory or the parent factory
+func (_this *BeanFactory) hasProvidedBean(match func(p *beanProvider) bool) bool {
+	for _, p := range _this.providers {
+		if match(p) {
+			return true
+		}
+	}
+	return _this.Parent != nil && _this.Parent.hasProvidedBean(match)
+}
+
+// SetActiveProfiles set the active profiles, it overrides the environment variable GO_SPRING_PROFILES_ACTIVE.
+// it returns ErrContainerFrozen after Freeze. 设置激活的 profile
+func (_this *BeanFactory) SetActiveProfiles(profiles ...string) error {
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "SetActiveProfiles", BeanName: "<profiles>"}
+	}
+	_this.activeProfiles = nil
+	for _, profile := range profiles {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			_this.activeProfiles = append(_this.activeProfiles, profile)
+		}
+	}
+	_this.profilesSet = true
+	return nil
+}
+
+// ActiveProfiles get the active profiles, set by SetActiveProfiles or the environment variable GO_SPRING_PROFILES_ACTIVE
+func (_this *BeanFactory) ActiveProfiles() []string {
+	if _this.profilesSet {
+		return _this.activeProfiles
+	}
+	var profiles []string
+	for _, profile := range strings.Split(os.Getenv(ProfilesEnv), ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// AcceptsProfiles checks whether any of the profiles is active, "!dev" is active if dev is not active.
+// empty profiles are always accepted. 是否接受 profile
+func (_this *BeanFactory) AcceptsProfiles(profiles ...string) bool {
+	if len(profiles) == 0 {
+		return true
+	}
+	active := make(map[string]bool)
+	for _, profile := range _this.ActiveProfiles() {
+		active[profile] = true
+	}
+	for _, profile := range profiles {
+		profile = strings.TrimSpace(profile)
+		if strings.HasPrefix(profile, "!") {
+			if !active[strings.TrimSpace(profile[1:])] {
+				return true
+			}
+		} else if active[profile] {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPropertyResolver set the PropertyResolver used by OnProperty, it returns ErrContainerFrozen after Freeze.
+// 设置属性解析器
+func (_this *BeanFactory) SetPropertyResolver(resolver PropertyResolver) error {
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "SetPropertyResolver", BeanName: "<property resolver>"}
+	}
+	_this.propertyResolver = resolver
+	return nil
+}
+
+// resolveProperty resolve the property by the PropertyResolver, or the Environment if it is not set
+func (_this *BeanFactory) resolveProperty(key string) (string, bool) {
+	if _this.propertyResolver != nil {
+		return _this.propertyResolver.GetProperty(key)
+	}
+	if _this.environment != nil {
+		value, ok := _this.environment.GetProperty(key)
+		if resolved, err := _this.environment.Resolve(value); ok && err == nil {
+			value = resolved
+		}
+		return value, ok
+	}
+	if _this.Parent != nil {
+		return _this.Parent.resolveProperty(key)
+	}
+	return os.LookupEnv(key)
+}
+
+// isConditional checks whether the config has profiles, conditions or conditional beans
+func (_this *BeanConfig) isConditional() bool {
+	if len(_this.Profiles) > 0 || len(_this.Conditions) > 0 {
+		return true
+	}
+	for _, bean := range _this.SingleBeans {
+		if _, ok := bean.(*ConditionalBean); ok {
+			return true
+		}
+	}
+	for _, bean := range _this.MultiBeans {
+		if _, ok := bean.(*ConditionalBean); ok {
+			return true
+		}
+	}
+	for _, provider := range _this.Providers {
+		if _, ok := provider.(*ConditionalBean); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesConditions checks the conditions in order, all of them must match
+func matchesConditions(factory *BeanFactory, conditions []Condition) bool {
+	for _, condition := range conditions {
+		if condition != nil && !condition.Matches(factory) {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrapConditional get the bean of the ConditionalBean, ok is false if the conditions do not match
+func (_this *BeanFactory) unwrapConditional(bean any) (ret any, ok bool) {
+	if conditionalBean, isConditional := bean.(*ConditionalBean); isConditional {
+		if !matchesConditions(_this, conditionalBean.Conditions) {
+			return nil, false
+		}
+		return conditionalBean.Bean, true
+	}
+	return bean, true
+}
+
+// RegisterConditionalConfigs evaluate the conditional configs in the order of AddBeanConfig and register the beans of
+// the matched ones, it is called before BeanCreated, so the conditions see the beans of all unconditional configs and
+// the conditional configs before them, including the beans of their providers, which are invoked after it. 注册条件配置
+func (_this *BeanFactory) RegisterConditionalConfigs() (err error) {
+	configs := _this.conditionalConfigs
+	_this.conditionalConfigs = nil
+	for _, beanConfig := range configs {
+		if !_this.AcceptsProfiles(beanConfig.Profiles...) || !matchesConditions(_this, beanConfig.Conditions) {
+			continue
+		}
+		if err = _this.addBeanConfig(beanConfig); err != nil {
+			return
+		}
+	}
+	return
+}
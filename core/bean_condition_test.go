@@ -0,0 +1,195 @@
+package core_test
+
+import (
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"testing"
+)
+
+type conditionStore interface {
+	Name() string
+}
+
+type conditionDB struct {
+	name string
+}
+
+func (db *conditionDB) Name() string {
+	return db.name
+}
+
+// conditionRepo the bean registered on conditions, it has no field to inject
+type conditionRepo struct{}
+
+// newConditionFactory the factory with the default DB, it is registered unless someone provides one
+func newConditionFactory(t *testing.T, condition core.Condition) *core.BeanFactory {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{core.Conditional(&conditionDB{name: "default"}, condition)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory
+}
+
+func TestOnMissingBeanProvided(t *testing.T) {
+	conditions := map[string]core.Condition{
+		"type string":    core.OnMissingBean("core_test.conditionDB"),
+		"reflect.Type":   core.OnMissingBean(reflect.TypeOf(&conditionDB{})),
+		"interface type": core.OnMissingBean(reflect.TypeOf((*conditionStore)(nil)).Elem()),
+	}
+	for name, condition := range conditions {
+		t.Run(name, func(t *testing.T) {
+			factory := newConditionFactory(t, condition)
+			err := factory.AddBeanConfig(&core.BeanConfig{
+				Providers: []any{func() *conditionDB { return &conditionDB{name: "provided"} }},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runPhases(t, factory)
+			if db := core.MustGet[*conditionDB](factory); db.Name() != "provided" {
+				t.Errorf("got the DB %q, want the provided one", db.Name())
+			}
+		})
+	}
+}
+
+func TestOnMissingBeanDefault(t *testing.T) {
+	factory := newConditionFactory(t, core.OnMissingBean(reflect.TypeOf(&conditionDB{})))
+	runPhases(t, factory)
+	if db := core.MustGet[*conditionDB](factory); db.Name() != "default" {
+		t.Errorf("got the DB %q, want the default one", db.Name())
+	}
+}
+
+func TestOnBeanProvided(t *testing.T) {
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		Providers: []any{func() *conditionDB { return &conditionDB{name: "provided"} }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = factory.AddBeanConfig(&core.BeanConfig{
+		Conditions:  []core.Condition{core.OnBean("core_test.conditionDB")},
+		SingleBeans: []any{&conditionRepo{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	if !factory.HasBean("core_test.conditionRepo") {
+		t.Error("the config on the provided bean is not registered")
+	}
+}
+
+// conditionResolver the PropertyResolver of the map
+type conditionResolver map[string]string
+
+func (r conditionResolver) GetProperty(key string) (string, bool) {
+	value, ok := r[key]
+	return value, ok
+}
+
+func TestOnProperty(t *testing.T) {
+	factory := core.NewBeanFactory()
+	factory.Environment().AddFirst(mapSource("test",
+		"cache.type", " redis ",
+		"cache.enabled", "",
+		"cache.disabled", "FALSE",
+		"cache.ref", "${cache.type}",
+	))
+	tests := []struct {
+		key   string
+		value string
+		want  bool
+	}{
+		{"cache.type", "redis", true},
+		{"cache.type", "memory", false},
+		{"cache.type", "", true},
+		{"cache.enabled", "", true},
+		{"cache.disabled", "", false},
+		{"cache.ref", "redis", true},
+		{"cache.missing", "", false},
+		{"cache.missing", "redis", false},
+	}
+	for _, test := range tests {
+		if got := core.OnProperty(test.key, test.value).Matches(factory); got != test.want {
+			t.Errorf("OnProperty(%q, %q) got %v, want %v", test.key, test.value, got, test.want)
+		}
+	}
+
+	if err := factory.SetPropertyResolver(conditionResolver{"cache.missing": "redis"}); err != nil {
+		t.Fatal(err)
+	}
+	if !core.OnProperty("cache.missing", "redis").Matches(factory) {
+		t.Error("OnProperty does not use the PropertyResolver")
+	}
+	if core.OnProperty("cache.type", "").Matches(factory) {
+		t.Error("OnProperty uses the environment when the PropertyResolver is set")
+	}
+}
+
+func TestOnProfile(t *testing.T) {
+	t.Setenv(core.ProfilesEnv, "")
+	factory := core.NewBeanFactory()
+	if err := factory.SetActiveProfiles(" dev ", "", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if got := factory.ActiveProfiles(); !reflect.DeepEqual(got, []string{"dev", "test"}) {
+		t.Errorf("got the active profiles %v", got)
+	}
+	tests := []struct {
+		profiles []string
+		want     bool
+	}{
+		{nil, true},
+		{[]string{"dev"}, true},
+		{[]string{"prod"}, false},
+		{[]string{"prod", "test"}, true},
+		{[]string{"!dev"}, false},
+		{[]string{"!prod"}, true},
+		{[]string{"prod", "! dev"}, false},
+	}
+	for _, test := range tests {
+		if got := core.OnProfile(test.profiles...).Matches(factory); got != test.want {
+			t.Errorf("OnProfile(%v) got %v, want %v", test.profiles, got, test.want)
+		}
+	}
+}
+
+// TestProfilesEnv the profiles are read from GO_SPRING_PROFILES_ACTIVE until SetActiveProfiles is called
+func TestProfilesEnv(t *testing.T) {
+	t.Setenv(core.ProfilesEnv, "prod, ,cloud")
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	if got := factory.ActiveProfiles(); !reflect.DeepEqual(got, []string{"prod", "cloud"}) {
+		t.Errorf("got the active profiles %v, want the environment variable", got)
+	}
+	err := factory.AddBeanConfig(&core.BeanConfig{Profiles: []string{"cloud"}, SingleBeans: []any{&conditionRepo{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = factory.AddBeanConfig(&core.BeanConfig{Profiles: []string{"dev"}, SingleBeans: []any{&conditionDB{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	if !factory.HasBean("core_test.conditionRepo") || factory.HasBean("core_test.conditionDB") {
+		t.Error("the configs are not registered by the profiles of the environment variable")
+	}
+
+	overridden := core.NewBeanFactory()
+	if err = overridden.SetActiveProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	if got := overridden.ActiveProfiles(); len(got) != 0 {
+		t.Errorf("SetActiveProfiles does not override the environment variable: %v", got)
+	}
+}
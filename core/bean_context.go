@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// RootContext the root context of the factory, it is cancelled by Shutdown, such as on SIGINT or SIGTERM in
+// application.RunAndWait. the long-running code should stop when it is done. 根上下文, 关闭时被取消
+func (_this *BeanFactory) RootContext() context.Context {
+	_this.ctxLock.Lock()
+	defer _this.ctxLock.Unlock()
+	if _this.rootCtx == nil {
+		_this.rootCtx, _this.rootCancel = context.WithCancel(context.Background())
+	}
+	return _this.rootCtx
+}
+
+// Shutdown cancel the root context, the main goroutines should return after it. 取消根上下文
+func (_this *BeanFactory) Shutdown() {
+	_this.RootContext()
+	_this.rootCancel()
+}
+
+// Go run fn in a main goroutine tracked by the factory, WaitMain waits for it. such as the useGo func of OnMainHandler.
+// 在受管理的协程中执行 fn
+func (_this *BeanFactory) Go(fn func()) {
+	if fn == nil {
+		return
+	}
+	_this.mainLock.Lock()
+	if _this.mainCount == 0 {
+		_this.mainDone = make(chan struct{})
+	}
+	_this.mainCount++
+	_this.mainLock.Unlock()
+	go func() {
+		defer func() {
+			_this.mainLock.Lock()
+			defer _this.mainLock.Unlock()
+			if _this.mainCount--; _this.mainCount == 0 {
+				close(_this.mainDone)
+			}
+		}()
+		fn()
+	}()
+}
+
+// MainDone returns a channel closed when all main goroutines started by Go return, it is closed if there are none.
+// the calls share the channel until a main goroutine is started after it is closed. 所有受管理协程结束时关闭
+func (_this *BeanFactory) MainDone() <-chan struct{} {
+	_this.mainLock.Lock()
+	defer _this.mainLock.Unlock()
+	if _this.mainDone == nil {
+		_this.mainDone = make(chan struct{})
+		close(_this.mainDone)
+	}
+	return _this.mainDone
+}
+
+// WaitMain wait for all main goroutines started by Go, it returns false if the timeout is exceeded, 0 is no timeout.
+// 等待所有受管理协程结束
+func (_this *BeanFactory) WaitMain(timeout time.Duration) bool {
+	done := _this.MainDone()
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,58 @@
+package core_test
+
+import (
+	"github.com/cutexingluo/go-spring/core"
+	"testing"
+	"time"
+)
+
+// closed checks whether the channel is closed
+func closed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestMainDone(t *testing.T) {
+	factory := core.NewBeanFactory()
+	if !closed(factory.MainDone()) {
+		t.Error("MainDone is not closed without main goroutines")
+	}
+
+	release := make(chan struct{})
+	factory.Go(func() {
+		<-factory.RootContext().Done()
+	})
+	factory.Go(func() {
+		<-release
+	})
+	done := factory.MainDone()
+	if closed(done) || factory.MainDone() != done {
+		t.Fatal("MainDone is closed or not shared while the main goroutines run")
+	}
+	if factory.WaitMain(10 * time.Millisecond) {
+		t.Error("WaitMain returns true before the main goroutines return")
+	}
+	factory.Shutdown()
+	close(release)
+	if !factory.WaitMain(5 * time.Second) {
+		t.Fatal("the main goroutines do not return after Shutdown")
+	}
+	if !closed(done) {
+		t.Error("MainDone is not closed after the main goroutines return")
+	}
+
+	// a new main goroutine gets a new channel
+	release = make(chan struct{})
+	factory.Go(func() {
+		<-release
+	})
+	if closed(factory.MainDone()) {
+		t.Error("MainDone is closed while the new main goroutine runs")
+	}
+	close(release)
+	factory.WaitMain(0)
+}
@@ -1,6 +1,9 @@
 package core
 
-import "reflect"
+import (
+	"context"
+	"reflect"
+)
 
 // LifeCycle  life cycle, in file mode. 生命周期接口, 此处为文件模式
 type LifeCycle interface {
@@ -74,10 +77,81 @@ type DestroyHandler interface {
 	Destroy(factory *BeanFactory) error
 }
 
+// AfterInstantiationCtxHandler the context-aware AfterInstantiationHandler, it is used first if both exist.
+// ctx is the context of application.RunContext, the startup can be bounded by its deadline. 带上下文的生命周期接口
+type AfterInstantiationCtxHandler interface {
+	// AfterInstantiationCtx - like AfterInstantiation, with the context of the startup
+	AfterInstantiationCtx(ctx context.Context, factory *BeanFactory) error
+}
+
+// AfterInitializationCtxHandler the context-aware AfterInitializationHandler, it is used first if both exist. 带上下文的生命周期接口
+type AfterInitializationCtxHandler interface {
+	// AfterInitializationCtx - like AfterInitialization, with the context of the startup
+	AfterInitializationCtx(ctx context.Context, factory *BeanFactory) error
+}
+
+// MainCtxHandler the context-aware MainHandler, it is used first if both exist. the ctx is cancelled on shutdown.
+// 带上下文的生命周期接口, 关闭时 ctx 被取消
+type MainCtxHandler interface {
+	// OnMainCtx - like OnMain of MainHandler, with the context cancelled on shutdown
+	OnMainCtx(ctx context.Context, factory *BeanFactory) error
+}
+
+// OnMainCtxHandler the context-aware OnMainHandler, it is used first if it exists. the ctx is cancelled on shutdown,
+// and it is also passed to useGo.  带上下文的生命周期接口, 关闭时 ctx 被取消
+type OnMainCtxHandler interface {
+	// OnMainCtx - like OnMain of OnMainHandler, useGo is executed in a main goroutine with the same ctx
+	OnMainCtx(ctx context.Context, factory *BeanFactory) (useGo func(ctx context.Context), runDestroy bool, err error)
+}
+
+// DestroyCtxHandler the context-aware DestroyHandler, it is used first if both exist. the ctx is done when the
+// destroy timeout is exceeded.  带上下文的销毁接口, 超时时 ctx 结束
+type DestroyCtxHandler interface {
+	// DestroyCtx - like Destroy, with the context of the shutdown
+	DestroyCtx(ctx context.Context, factory *BeanFactory) error
+}
+
+// InitializingBean the bean is initialized by itself after the tag initialization and the injection, in the order of
+// the dependencies. it is the bean registered by BeanConfig, not the life cycle object. bean 的初始化回调
+type InitializingBean interface {
+	// AfterPropertiesSet - the fields of the bean are initialized and injected, the dependencies are initialized.
+	AfterPropertiesSet() error
+}
+
+// DisposableBean the bean is destroyed by itself in BeanFactory.Close, in the reverse order of the dependencies.
+// bean 的销毁回调
+type DisposableBean interface {
+	// Destroy - release the resources of the bean, the dependents are destroyed.
+	Destroy() error
+}
+
+// Ordered the order of the bean when it is injected into a collection, such as `bean:"pkg.Handler,all"`.
+// 注入集合时 bean 的顺序
+type Ordered interface {
+	// Order - the smaller order comes first.  越小越靠前
+	Order() int
+}
+
+// PrimaryBean the bean is the default one when several beans match the type.  同类型存在多个 bean 时的默认 bean
+type PrimaryBean interface {
+	// IsPrimary - whether the bean is the primary bean of its type.  是否为主 bean
+	IsPrimary() bool
+}
+
 // BeanConfig  配置的bean, 只能配一个。如果两个配了，则使用MultiBeans
 type BeanConfig struct {
 	SingleBeans []interface{}          // single bean 单例Bean
 	MultiBeans  map[string]interface{} // multi beans 注入的 bean列表,  map[beanName]bean
+	ScopedBeans map[string]*ScopedBean // scoped beans, such as prototype beans 作用域 bean,  map[beanName]definition
+	Providers   []interface{}          // constructors of single beans, such as func(db *DB) (*Repo, error) 构造函数
+	Primary     []string               // the primary bean names, used when several beans match the type 主 bean 名称
+	Aliases     map[string]string      // the aliases of the beans, map[alias]beanName 别名
+
+	// Profiles the config is registered only if any of the profiles is active, "!dev" is active if dev is not active,
+	// empty is always registered. 激活的 profile
+	Profiles []string
+	// Conditions the config is registered only if all conditions match, such as core.OnMissingBean("pkg.DB"). 注册条件
+	Conditions []Condition
 }
 
 // BeanFilterFunction bean filter function
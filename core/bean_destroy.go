@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/base"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// Close destroy all beans in the container in the reverse order of the dependencies (the dependents first), so
+// the connections and files are released after the beans using them. the bean is destroyed by the first of
+// DestroyCtxHandler, DestroyHandler, DisposableBean and io.Closer it implements. each bean is limited by DestroyTimeout, the
+// errors are collected in ErrBeanDestroy. Close can only be called once, the later calls do nothing.
+// 按依赖的逆序销毁容器中的所有 bean
+func (_this *BeanFactory) Close() error {
+	return _this.CloseContext(context.Background())
+}
+
+// CloseContext like Close, the ctx is passed to the DestroyCtxHandler beans with the DestroyTimeout, and the beans
+// left are not destroyed if the ctx is done. 带上下文的 Close
+func (_this *BeanFactory) CloseContext(ctx context.Context) error {
+	if !_this.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	var destroyErr *ErrBeanDestroy
+	destroyed := make(map[beanKey]bool) // the same bean may be added by different names
+	for _, beanName := range _this.destroyOrder() {
+		if !_this.BeanContainer.HasBean(beanName) { // the beans of the parent factory are destroyed by the parent
+			continue
+		}
+		bean, err := _this.GetBean(beanName)
+		if err != nil || bean == nil {
+			continue
+		}
+		if key, ok := beanKeyOf(bean); ok {
+			if destroyed[key] {
+				continue
+			}
+			destroyed[key] = true
+		}
+		if err = ctx.Err(); err == nil {
+			err = _this.destroyBean(ctx, beanName, bean)
+		}
+		if err != nil {
+			if destroyErr == nil {
+				destroyErr = &ErrBeanDestroy{Errors: make(map[string]error)}
+			}
+			destroyErr.Errors[beanName] = err
+			destroyErr.Order = append(destroyErr.Order, beanName)
+		}
+	}
+	if destroyErr != nil {
+		return destroyErr
+	}
+	return nil
+}
+
+// IsClosed checks whether the factory is closed
+func (_this *BeanFactory) IsClosed() bool {
+	return _this.closed.Load()
+}
+
+// destroyOrder the reverse of the BeanInjected order, if the beans are not injected, use the topo layers
+func (_this *BeanFactory) destroyOrder() []string {
+	order := append([]string(nil), _this.initOrder...)
+	if len(order) == 0 {
+		if layers, err := _this.BeanTopo.BuildLayers(); err == nil {
+			for _, layer := range layers {
+				order = append(order, layer...)
+			}
+		}
+	}
+	inOrder := make(map[string]bool, len(order))
+	for _, beanName := range order {
+		inOrder[beanName] = true
+	}
+	var others []string // the beans added after the injection or not in the topo
+	for _, beanName := range _this.BeanContainer.GetAllBeanNames() {
+		if !inOrder[beanName] {
+			others = append(others, beanName)
+		}
+	}
+	sort.Strings(others)
+	order = append(order, others...)
+	base.Reverse(order)
+	return order
+}
+
+// destroyBean destroy the bean with the DestroyTimeout
+func (_this *BeanFactory) destroyBean(ctx context.Context, beanName string, bean any) error {
+	if _this.DestroyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, _this.DestroyTimeout)
+		defer cancel()
+	}
+	var destroy func() error
+	if destroyCtxHandler, ok := bean.(DestroyCtxHandler); ok {
+		destroy = func() error {
+			return destroyCtxHandler.DestroyCtx(ctx, _this)
+		}
+	} else if destroyHandler, ok := bean.(DestroyHandler); ok {
+		destroy = func() error {
+			return destroyHandler.Destroy(_this)
+		}
+	} else if disposableBean, ok := bean.(DisposableBean); ok {
+		destroy = disposableBean.Destroy
+	} else if closer, ok := bean.(io.Closer); ok {
+		destroy = closer.Close
+	} else {
+		return nil
+	}
+	if ctx.Done() == nil { // no timeout and never cancelled
+		return destroy()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- destroy()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && _this.DestroyTimeout > 0 {
+			return fmt.Errorf("the bean '%s' is not destroyed in %s: %w", beanName, _this.DestroyTimeout, ctx.Err())
+		}
+		return fmt.Errorf("the bean '%s' is not destroyed: %w", beanName, ctx.Err())
+	}
+}
+
+// beanKey the identity of a ptr bean
+type beanKey struct {
+	beanType reflect.Type
+	pointer  uintptr // the pointers of zero-size beans may be equal, so the type is in the key
+}
+
+// beanKeyOf get the identity of the bean, ok is false if it is not a ptr
+func beanKeyOf(bean any) (key beanKey, ok bool) {
+	value := reflect.ValueOf(bean)
+	if value.Kind() != reflect.Ptr {
+		return key, false
+	}
+	return beanKey{beanType: value.Type(), pointer: value.Pointer()}, true
+}
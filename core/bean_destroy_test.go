@@ -0,0 +1,225 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// destroyRecorder record the names of the destroyed beans
+type destroyRecorder struct {
+	name  string
+	order *[]string
+	err   error
+}
+
+func (r *destroyRecorder) Destroy() error {
+	*r.order = append(*r.order, r.name)
+	return r.err
+}
+
+type destroyDB struct {
+	destroyRecorder
+}
+
+type destroyRepo struct {
+	destroyRecorder
+	DB *destroyDB `bean:"core_test.destroyDB"`
+}
+
+type destroyService struct {
+	destroyRecorder
+	Repo *destroyRepo `bean:"core_test.destroyRepo"`
+}
+
+// destroyHanging the Destroy blocks until release is closed
+type destroyHanging struct {
+	release chan struct{}
+}
+
+func (h *destroyHanging) Destroy() error {
+	<-h.release
+	return nil
+}
+
+// newDestroyFactory the factory of the service -> repo -> db beans, after the phases
+func newDestroyFactory(t *testing.T, order *[]string, beans ...any) *core.BeanFactory {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{SingleBeans: append([]any{
+		&destroyService{destroyRecorder: destroyRecorder{name: "service", order: order}},
+		&destroyDB{destroyRecorder: destroyRecorder{name: "db", order: order}},
+		&destroyRepo{destroyRecorder: destroyRecorder{name: "repo", order: order}},
+	}, beans...)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	return factory
+}
+
+// TestCloseOrder the dependents are destroyed first, and Close only works once
+func TestCloseOrder(t *testing.T) {
+	var order []string
+	factory := newDestroyFactory(t, &order)
+	if err := factory.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"service", "repo", "db"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("got the order %v, want %v", order, want)
+	}
+	if !factory.IsClosed() {
+		t.Error("the factory is not closed")
+	}
+	if err := factory.Close(); err != nil || len(order) != 3 {
+		t.Errorf("the second Close got the error %v and destroyed %v", err, order)
+	}
+}
+
+// TestCloseDedup the bean added by several names is destroyed once
+func TestCloseDedup(t *testing.T) {
+	var order []string
+	factory := core.NewBeanFactory()
+	shared := &destroyRecorder{name: "shared", order: &order}
+	for _, beanName := range []string{"first", "second"} {
+		if _, err := factory.AddMultiBean(beanName, shared); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := factory.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"shared"}) {
+		t.Errorf("got %v, want the bean destroyed once", order)
+	}
+}
+
+// TestCloseErrors the errors and the timeouts are collected in ErrBeanDestroy, the other beans are still destroyed
+func TestCloseErrors(t *testing.T) {
+	var order []string
+	hanging := &destroyHanging{release: make(chan struct{})}
+	defer close(hanging.release)
+	factory := newDestroyFactory(t, &order, hanging)
+	repoErr := errors.New("repo failed")
+	core.MustGet[*destroyRepo](factory).err = repoErr
+	factory.DestroyTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	err := factory.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close waited %s for the hanging bean", elapsed)
+	}
+	var destroyErr *core.ErrBeanDestroy
+	if !errors.As(err, &destroyErr) {
+		t.Fatalf("got the error %v, want ErrBeanDestroy", err)
+	}
+	if !errors.Is(destroyErr.Errors["core_test.destroyRepo"], repoErr) {
+		t.Errorf("got the repo error %v", destroyErr.Errors["core_test.destroyRepo"])
+	}
+	hangingErr := destroyErr.Errors["core_test.destroyHanging"]
+	if !errors.Is(hangingErr, context.DeadlineExceeded) || !strings.Contains(hangingErr.Error(), "20ms") {
+		t.Errorf("got the hanging error %v, want the DestroyTimeout", hangingErr)
+	}
+	if len(destroyErr.Order) != 2 || len(destroyErr.Errors) != 2 {
+		t.Errorf("got the failed beans %v, want the repo and the hanging bean", destroyErr.Order)
+	}
+	if want := []string{"service", "repo", "db"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("got the order %v, want %v", order, want)
+	}
+}
+
+// TestCloseContextDone the beans are not destroyed after the ctx is done
+func TestCloseContextDone(t *testing.T) {
+	var order []string
+	factory := newDestroyFactory(t, &order)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := factory.CloseContext(ctx)
+	var destroyErr *core.ErrBeanDestroy
+	if !errors.As(err, &destroyErr) {
+		t.Fatalf("got the error %v, want ErrBeanDestroy", err)
+	}
+	for _, beanName := range []string{"core_test.destroyService", "core_test.destroyRepo", "core_test.destroyDB"} {
+		if !errors.Is(destroyErr.Errors[beanName], context.Canceled) {
+			t.Errorf("got the error %v of '%s', want context.Canceled", destroyErr.Errors[beanName], beanName)
+		}
+	}
+	if len(order) != 0 {
+		t.Errorf("the beans %v are destroyed after the ctx is done", order)
+	}
+}
+
+// destroyAll implements all destroy interfaces, calls records which one is called
+type destroyAll struct {
+	calls []string
+}
+
+func (d *destroyAll) DestroyCtx(ctx context.Context, factory *core.BeanFactory) error {
+	d.calls = append(d.calls, "DestroyCtx")
+	return nil
+}
+
+func (d *destroyAll) Destroy() error {
+	d.calls = append(d.calls, "Destroy")
+	return nil
+}
+
+func (d *destroyAll) Close() error {
+	d.calls = append(d.calls, "Close")
+	return nil
+}
+
+// destroyCloser only implements DisposableBean and io.Closer
+type destroyCloser struct {
+	calls []string
+}
+
+func (d *destroyCloser) Destroy() error {
+	d.calls = append(d.calls, "Destroy")
+	return nil
+}
+
+func (d *destroyCloser) Close() error {
+	d.calls = append(d.calls, "Close")
+	return nil
+}
+
+// destroyFile only implements io.Closer
+type destroyFile struct {
+	closed bool
+}
+
+func (d *destroyFile) Close() error {
+	d.closed = true
+	return nil
+}
+
+// TestCloseHooks the bean is destroyed by itself, by the first of DestroyCtxHandler, DestroyHandler, DisposableBean and
+// io.Closer
+func TestCloseHooks(t *testing.T) {
+	all, closer, file := &destroyAll{}, &destroyCloser{}, &destroyFile{}
+	factory := core.NewBeanFactory()
+	for _, bean := range []any{all, closer, file} {
+		if _, err := factory.AddSingleBean(bean); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := factory.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all.calls, []string{"DestroyCtx"}) {
+		t.Errorf("got the calls %v, want DestroyCtx only", all.calls)
+	}
+	if !reflect.DeepEqual(closer.calls, []string{"Destroy"}) {
+		t.Errorf("got the calls %v, want Destroy only", closer.calls)
+	}
+	if !file.closed {
+		t.Error("the io.Closer bean is not closed")
+	}
+}
@@ -0,0 +1,447 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	CommandLineSourceName = "commandLineArgs"   // the name of the command-line property source
+	EnvSourceName         = "systemEnvironment" // the name of the environment variable property source
+	PlaceholderPrefix     = "${"                // the prefix of the placeholder, such as ${server.port:8080}
+	PlaceholderSuffix     = "}"                 // the suffix of the placeholder
+	PlaceholderSeparator  = ":"                 // the separator of the key and the default value
+)
+
+// PropertySource a named source of the properties, such as the environment variables or a config file. 属性源
+type PropertySource interface {
+	Name() string                                   // Name - the unique name of the source
+	GetProperty(key string) (value string, ok bool) // GetProperty - get the property, ok is false if it is not found
+	Keys() []string                                 // Keys - all keys of the source
+}
+
+// MapPropertySource the property source of a map. map 属性源
+type MapPropertySource struct {
+	name       string
+	properties map[string]string
+}
+
+// NewMapPropertySource create the property source of the map, the map is copied
+func NewMapPropertySource(name string, properties map[string]string) *MapPropertySource {
+	copied := make(map[string]string, len(properties))
+	for key, value := range properties {
+		copied[key] = value
+	}
+	return &MapPropertySource{name: name, properties: copied}
+}
+
+// Name the name of the source
+func (_this *MapPropertySource) Name() string {
+	return _this.name
+}
+
+// GetProperty get the property
+func (_this *MapPropertySource) GetProperty(key string) (string, bool) {
+	value, ok := _this.properties[key]
+	return value, ok
+}
+
+// Keys all keys, sorted
+func (_this *MapPropertySource) Keys() []string {
+	keys := make([]string, 0, len(_this.properties))
+	for key := range _this.properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EnvPropertySource the property source of the environment variables, the key server.port also matches SERVER_PORT.
+// 环境变量属性源
+type EnvPropertySource struct{}
+
+// NewEnvPropertySource create the property source of the environment variables
+func NewEnvPropertySource() *EnvPropertySource {
+	return &EnvPropertySource{}
+}
+
+// Name the name of the source
+func (_this *EnvPropertySource) Name() string {
+	return EnvSourceName
+}
+
+// GetProperty get the environment variable by the key, or by the key in upper case with '.' and '-' replaced by '_'
+func (_this *EnvPropertySource) GetProperty(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	return os.LookupEnv(ToEnvKey(key))
+}
+
+// ToEnvKey convert the property key to the environment variable name, such as server.port -> SERVER_PORT
+func ToEnvKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", "[", "_", "]", "").Replace(key))
+}
+
+// Keys the names of all environment variables, sorted
+func (_this *EnvPropertySource) Keys() []string {
+	var keys []string
+	for _, kv := range os.Environ() {
+		if index := strings.Index(kv, "="); index > 0 {
+			keys = append(keys, kv[:index])
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NewCommandLinePropertySource create the property source of the arguments like --key=value, --key is "true".
+// the other arguments are ignored. 命令行属性源
+func NewCommandLinePropertySource(args []string) *MapPropertySource {
+	properties := make(map[string]string)
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			continue
+		}
+		key, value, found := strings.Cut(arg[2:], "=")
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if !found {
+			value = "true"
+		}
+		properties[key] = value
+	}
+	return &MapPropertySource{name: CommandLineSourceName, properties: properties}
+}
+
+// NewFilePropertySource create the property source of the file in the properties format, see ParseProperties. 文件属性源
+func NewFilePropertySource(path string) (*MapPropertySource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	properties, err := ParseProperties(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+	return &MapPropertySource{name: path, properties: properties}, nil
+}
+
+// ParseProperties parse the properties format, such as server.port=8080 or server.port: 8080. the lines beginning with
+// '#' or '!' are comments, and a line ending with '\' is continued on the next line. 解析 properties 格式
+func ParseProperties(reader io.Reader) (map[string]string, error) {
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+	text := ""
+	for line := 1; scanner.Scan(); line++ {
+		text += strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(text, "\\") {
+			text = text[:len(text)-1]
+			continue
+		}
+		if text != "" && text[0] != '#' && text[0] != '!' {
+			index := strings.IndexAny(text, "=:")
+			if index <= 0 {
+				return nil, fmt.Errorf("invalid property at line %d: '%s'", line, text)
+			}
+			properties[strings.TrimSpace(text[:index])] = strings.TrimSpace(text[index+1:])
+		}
+		text = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// Environment the ordered property sources, the first source containing the key wins. it is registered as a bean
+// before BeanCreated, and it is the default PropertyResolver of the factory. 环境, 有序的属性源
+type Environment struct {
+	lock    sync.RWMutex
+	sources []PropertySource
+}
+
+// NewEnvironment create the environment with the sources, the first has the highest priority
+func NewEnvironment(sources ...PropertySource) *Environment {
+	return &Environment{sources: append([]PropertySource(nil), sources...)}
+}
+
+// NewStandardEnvironment create the environment with the command-line arguments (os.Args) and the environment variables,
+// the command-line arguments come first. 标准环境
+func NewStandardEnvironment() *Environment {
+	return NewEnvironment(NewCommandLinePropertySource(os.Args[1:]), NewEnvPropertySource())
+}
+
+// AddFirst add the source with the highest priority, the source of the same name is replaced
+func (_this *Environment) AddFirst(source PropertySource) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	_this.sources = append([]PropertySource{source}, _this.removeSource(source.Name())...)
+}
+
+// AddLast add the source with the lowest priority, the source of the same name is replaced
+func (_this *Environment) AddLast(source PropertySource) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	_this.sources = append(_this.removeSource(source.Name()), source)
+}
+
+// AddBefore add the source before the source named relativeName, it is added last if relativeName is not found
+func (_this *Environment) AddBefore(relativeName string, source PropertySource) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	sources := _this.removeSource(source.Name())
+	for index, existing := range sources {
+		if existing.Name() == relativeName {
+			_this.sources = append(sources[:index:index], append([]PropertySource{source}, sources[index:]...)...)
+			return
+		}
+	}
+	_this.sources = append(sources, source)
+}
+
+// Replace replace the source of the same name in place, it is added last if not found
+func (_this *Environment) Replace(source PropertySource) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	for index, existing := range _this.sources {
+		if existing.Name() == source.Name() {
+			_this.sources[index] = source
+			return
+		}
+	}
+	_this.sources = append(_this.sources, source)
+}
+
+// ReplaceSources replace the sources of the names by the sources at once, the new sources take the position of the first
+// old source, or are added last if none of them exists. 原子地替换一组属性源
+func (_this *Environment) ReplaceSources(names []string, sources []PropertySource) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	removed := make(map[string]bool, len(names)+len(sources))
+	for _, name := range names {
+		removed[name] = true
+	}
+	for _, source := range sources {
+		removed[source.Name()] = true
+	}
+	position := -1
+	kept := make([]PropertySource, 0, len(_this.sources)+len(sources))
+	for _, existing := range _this.sources {
+		if !removed[existing.Name()] {
+			kept = append(kept, existing)
+		} else if position < 0 {
+			position = len(kept)
+		}
+	}
+	if position < 0 {
+		position = len(kept)
+	}
+	_this.sources = append(append(kept[:position:position], sources...), kept[position:]...)
+}
+
+// Remove remove the source by name, returns whether it is removed
+func (_this *Environment) Remove(name string) bool {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	sources := _this.removeSource(name)
+	removed := len(sources) != len(_this.sources)
+	_this.sources = sources
+	return removed
+}
+
+// removeSource returns a copy of the sources without the source named name, the lock must be held
+func (_this *Environment) removeSource(name string) []PropertySource {
+	sources := make([]PropertySource, 0, len(_this.sources)+1)
+	for _, source := range _this.sources {
+		if source.Name() != name {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// GetPropertySource get the source by name
+func (_this *Environment) GetPropertySource(name string) (PropertySource, bool) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+	for _, source := range _this.sources {
+		if source.Name() == name {
+			return source, true
+		}
+	}
+	return nil, false
+}
+
+// GetPropertySources get a copy of the sources, ordered by priority
+func (_this *Environment) GetPropertySources() []PropertySource {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+	return append([]PropertySource(nil), _this.sources...)
+}
+
+// GetProperty get the raw property from the first source containing the key, the placeholders are not resolved.
+// it implements PropertyResolver
+func (_this *Environment) GetProperty(key string) (string, bool) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+	for _, source := range _this.sources {
+		if value, ok := source.GetProperty(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// GetPropertyOrDefault get the resolved property, or the defaultValue if it is not found or can not be resolved
+func (_this *Environment) GetPropertyOrDefault(key string, defaultValue string) string {
+	value, ok := _this.GetProperty(key)
+	if !ok {
+		return defaultValue
+	}
+	resolved, err := _this.Resolve(value)
+	if err != nil {
+		return defaultValue
+	}
+	return resolved
+}
+
+// Keys all keys of the sources, sorted and without duplicates
+func (_this *Environment) Keys() []string {
+	set := make(map[string]bool)
+	for _, source := range _this.GetPropertySources() {
+		for _, key := range source.Keys() {
+			set[key] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Resolve resolve the placeholders like ${server.port:8080} in the text, the default value after ':' is used if the key is
+// not found. the placeholders can be nested, such as ${app.name:${APP_NAME:demo}}, and the properties are resolved
+// recursively. ErrPropertyNotFound is returned if the key is not found without a default value. 解析占位符
+func (_this *Environment) Resolve(text string) (string, error) {
+	return _this.resolve(text, nil)
+}
+
+// resolve resolve the text, visiting is the keys being resolved, to find the circular references
+func (_this *Environment) resolve(text string, visiting []string) (string, error) {
+	start := strings.Index(text, PlaceholderPrefix)
+	if start < 0 {
+		return text, nil
+	}
+	builder := strings.Builder{}
+	for start >= 0 {
+		end := placeholderEnd(text, start)
+		if end < 0 {
+			return "", fmt.Errorf("the placeholder in '%s' is not closed", text)
+		}
+		builder.WriteString(text[:start])
+		value, err := _this.resolvePlaceholder(text[start+len(PlaceholderPrefix):end], visiting)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(value)
+		text = text[end+len(PlaceholderSuffix):]
+		start = strings.Index(text, PlaceholderPrefix)
+	}
+	builder.WriteString(text)
+	return builder.String(), nil
+}
+
+// resolvePlaceholder resolve the content of a placeholder, such as server.port:8080
+func (_this *Environment) resolvePlaceholder(placeholder string, visiting []string) (string, error) {
+	key, defaultValue, hasDefault := cutPlaceholder(placeholder)
+	key, err := _this.resolve(key, visiting)
+	if err != nil {
+		return "", err
+	}
+	key = strings.TrimSpace(key)
+	for _, k := range visiting {
+		if k == key {
+			return "", fmt.Errorf("the property '%s' forms a circular placeholder reference: %s -> %s",
+				key, strings.Join(visiting, " -> "), key)
+		}
+	}
+	if value, ok := _this.GetProperty(key); ok {
+		return _this.resolve(value, append(visiting, key))
+	}
+	if hasDefault {
+		return _this.resolve(defaultValue, visiting)
+	}
+	return "", &ErrPropertyNotFound{Key: key}
+}
+
+// placeholderEnd find the index of the suffix closing the placeholder at start, the nested placeholders are skipped
+func placeholderEnd(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		if strings.HasPrefix(text[i:], PlaceholderPrefix) {
+			depth++
+			i += len(PlaceholderPrefix) - 1
+		} else if strings.HasPrefix(text[i:], PlaceholderSuffix) {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// cutPlaceholder split the placeholder by the first separator outside the nested placeholders
+func cutPlaceholder(placeholder string) (key string, defaultValue string, found bool) {
+	depth := 0
+	for i := 0; i < len(placeholder); i++ {
+		if strings.HasPrefix(placeholder[i:], PlaceholderPrefix) {
+			depth++
+			i += len(PlaceholderPrefix) - 1
+		} else if strings.HasPrefix(placeholder[i:], PlaceholderSuffix) {
+			depth--
+		} else if depth == 0 && strings.HasPrefix(placeholder[i:], PlaceholderSeparator) {
+			return placeholder[:i], placeholder[i+len(PlaceholderSeparator):], true
+		}
+	}
+	return placeholder, "", false
+}
+
+// HasPlaceholder checks whether the text contains a placeholder
+func HasPlaceholder(text string) bool {
+	return strings.Contains(text, PlaceholderPrefix)
+}
+
+// Environment get the environment of the factory, the child factory shares the environment of the parent by default
+func (_this *BeanFactory) Environment() *Environment {
+	return _this.environment
+}
+
+// SetEnvironment set the environment of the factory, it must be called before BeanCreated, it returns
+// ErrContainerFrozen after Freeze. 设置环境
+func (_this *BeanFactory) SetEnvironment(environment *Environment) error {
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "SetEnvironment", BeanName: "<environment>"}
+	}
+	_this.environment = environment
+	return nil
+}
+
+// registerEnvironment add the environment as a single bean before BeanCreated, if it is not in the container
+func (_this *BeanFactory) registerEnvironment() error {
+	if _this.environment == nil {
+		return nil
+	}
+	_, err := _this.AddSingleBean(_this.environment) // not added if it exists
+	return err
+}
@@ -0,0 +1,171 @@
+package core_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sourceNames the names of the sources in order
+func sourceNames(env *core.Environment) []string {
+	var names []string
+	for _, source := range env.GetPropertySources() {
+		names = append(names, source.Name())
+	}
+	return names
+}
+
+// mapSource the source of the pairs key, value, ...
+func mapSource(name string, pairs ...string) *core.MapPropertySource {
+	properties := make(map[string]string)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		properties[pairs[i]] = pairs[i+1]
+	}
+	return core.NewMapPropertySource(name, properties)
+}
+
+func TestEnvironmentOrder(t *testing.T) {
+	env := core.NewEnvironment(mapSource("a", "k", "a"), mapSource("b", "k", "b"))
+	env.AddFirst(mapSource("first", "k", "first"))
+	env.AddLast(mapSource("last"))
+	env.AddBefore("b", mapSource("beforeB"))
+	env.AddBefore("missing", mapSource("unknownRelative"))
+	want := []string{"first", "a", "beforeB", "b", "last", "unknownRelative"}
+	if got := sourceNames(env); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if value, _ := env.GetProperty("k"); value != "first" {
+		t.Errorf("got k=%q, want the first source", value)
+	}
+
+	env.AddBefore("first", mapSource("b", "k", "moved")) // the source of the same name is moved
+	env.Replace(mapSource("a", "k", "replaced"))         // replaced in place
+	env.Replace(mapSource("new"))                        // added last
+	want = []string{"b", "first", "a", "beforeB", "last", "unknownRelative", "new"}
+	if got := sourceNames(env); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if value, _ := env.GetProperty("k"); value != "moved" {
+		t.Errorf("got k=%q, want the moved source", value)
+	}
+	if source, _ := env.GetPropertySource("a"); source == nil {
+		t.Error("the replaced source is not found")
+	} else if value, _ := source.GetProperty("k"); value != "replaced" {
+		t.Errorf("got k=%q of the replaced source", value)
+	}
+
+	env.ReplaceSources([]string{"first", "beforeB"}, []core.PropertySource{mapSource("x"), mapSource("y")})
+	want = []string{"b", "x", "y", "a", "last", "unknownRelative", "new"}
+	if got := sourceNames(env); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !env.Remove("x") || env.Remove("x") {
+		t.Error("Remove returns whether the source is removed")
+	}
+}
+
+func TestEnvironmentResolve(t *testing.T) {
+	env := core.NewEnvironment(mapSource("test",
+		"host", "local",
+		"port", "80",
+		"url", "http://${host}:${port}",
+		"name.key", "host",
+		"a", "${b}",
+		"b", "${c}",
+		"c", "${a}",
+		"self", "${self}",
+	))
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"${host}", "local"},
+		{"${url}/path", "http://local:80/path"},
+		{"${missing:def}", "def"},
+		{"${missing:}", ""},
+		{"${missing:${host}}", "local"},
+		{"${a1:${b1:c}}", "c"},
+		{"${a1:${b1:${port}}}", "80"},
+		{"${${name.key}}", "local"},
+		{"${missing:a:b}", "a:b"},
+	}
+	for _, test := range tests {
+		if got, err := env.Resolve(test.text); err != nil || got != test.want {
+			t.Errorf("Resolve(%q) got %q and %v, want %q", test.text, got, err, test.want)
+		}
+	}
+
+	var notFound *core.ErrPropertyNotFound
+	if _, err := env.Resolve("${missing}"); !errors.As(err, &notFound) || notFound.Key != "missing" {
+		t.Errorf("got the error %v, want ErrPropertyNotFound of missing", err)
+	}
+	if _, err := env.Resolve("${host"); err == nil || !strings.Contains(err.Error(), "not closed") {
+		t.Errorf("got the error %v, want the unclosed placeholder", err)
+	}
+	for text, cycle := range map[string]string{"${a}": "a -> b -> c -> a", "${self}": "self -> self"} {
+		if _, err := env.Resolve(text); err == nil || !strings.Contains(err.Error(), cycle) {
+			t.Errorf("Resolve(%q) got the error %v, want the circular reference %s", text, err, cycle)
+		}
+	}
+	if got := env.GetPropertyOrDefault("a", "def"); got != "def" {
+		t.Errorf("got %q for the circular property, want the default", got)
+	}
+	if got := env.GetPropertyOrDefault("url", "def"); got != "http://local:80" {
+		t.Errorf("got %q, want the resolved url", got)
+	}
+}
+
+func TestToEnvKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"server.port":      "SERVER_PORT",
+		"max-size":         "MAX_SIZE",
+		"servers[0].host":  "SERVERS_0_HOST",
+		"app.http2.tls-on": "APP_HTTP2_TLS_ON",
+		"ALREADY_ENV":      "ALREADY_ENV",
+	} {
+		if got := core.ToEnvKey(key); got != want {
+			t.Errorf("ToEnvKey(%q) got %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvPropertySource(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9090")
+	source := core.NewEnvPropertySource()
+	for _, key := range []string{"SERVER_PORT", "server.port"} {
+		if value, ok := source.GetProperty(key); !ok || value != "9090" {
+			t.Errorf("got %s=%q %v, want 9090", key, value, ok)
+		}
+	}
+}
+
+func TestCommandLinePropertySource(t *testing.T) {
+	source := core.NewCommandLinePropertySource([]string{"--a=1", "--flag", "-v", "plain", "--", "--=x", "--b=x=y"})
+	want := map[string]string{"a": "1", "flag": "true", "b": "x=y"}
+	got := make(map[string]string)
+	for _, key := range source.Keys() {
+		got[key], _ = source.GetProperty(key)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestFactoryEnvironment only the standard factory reads the command-line arguments and the environment variables, the
+// child factory shares the environment of the parent
+func TestFactoryEnvironment(t *testing.T) {
+	t.Setenv("GO_SPRING_TEST_KEY", "env")
+	if _, ok := core.NewBeanFactory().Environment().GetProperty("GO_SPRING_TEST_KEY"); ok {
+		t.Error("the plain factory reads the environment variables")
+	}
+	standard := core.NewStandardBeanFactory()
+	if value, _ := standard.Environment().GetProperty("go.spring.test.key"); value != "env" {
+		t.Errorf("the standard factory got %q, want the environment variable", value)
+	}
+	if child := core.NewChildBeanFactory(standard); child.Environment() != standard.Environment() {
+		t.Error("the child factory does not share the environment of the parent")
+	}
+}
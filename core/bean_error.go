@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrBeanNotFound the bean is not in the container. 容器中不存在该 bean
+type ErrBeanNotFound struct {
+	error
+	BeanName string // bean name or bean type
+}
+
+func (e *ErrBeanNotFound) Error() string {
+	return fmt.Sprintf("ErrBeanNotFound : the bean '%s' is not in the container", e.BeanName)
+}
+
+// ErrBeanNotUnique more than one bean matches the bean type. 同一类型存在多个 bean
+type ErrBeanNotUnique struct {
+	error
+	BeanType   string   // the required bean type
+	Candidates []string // the bean names that match the type
+}
+
+func (e *ErrBeanNotUnique) Error() string {
+	return fmt.Sprintf("ErrBeanNotUnique : expected a single bean of type '%s', but found %d: [%s]",
+		e.BeanType, len(e.Candidates), strings.Join(e.Candidates, ", "))
+}
+
+// ErrBeanTypeMismatch the bean can not be converted to the required type. bean 类型不匹配
+type ErrBeanTypeMismatch struct {
+	error
+	BeanName     string // bean name or bean type
+	RequiredType string // the required type
+	ActualType   string // the type of the bean in the container
+}
+
+func (e *ErrBeanTypeMismatch) Error() string {
+	return fmt.Sprintf("ErrBeanTypeMismatch : the bean '%s' is of type '%s', not '%s'", e.BeanName, e.ActualType, e.RequiredType)
+}
+
+// ErrBeanDestroy the errors of the beans destroyed by BeanFactory.Close. 销毁 bean 时的错误
+type ErrBeanDestroy struct {
+	error
+	Errors map[string]error // beanName -> error
+	Order  []string         // the failed bean names, in the order of destruction
+}
+
+func (e *ErrBeanDestroy) Error() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("ErrBeanDestroy : failed to destroy %d beans:", len(e.Order)))
+	for _, beanName := range e.Order {
+		builder.WriteString(fmt.Sprintf("\n  '%s': %v", beanName, e.Errors[beanName]))
+	}
+	return builder.String()
+}
+
+// ErrContainerFrozen the container is read-only after BeanFactory.Freeze. 容器已冻结
+type ErrContainerFrozen struct {
+	error
+	Operation string // the rejected operation, such as AddSingleBean
+	BeanName  string // bean name or bean type
+}
+
+func (e *ErrContainerFrozen) Error() string {
+	return fmt.Sprintf("ErrContainerFrozen : the container is frozen, '%s' of the bean '%s' is rejected", e.Operation, e.BeanName)
+}
+
+// ErrPropertyNotFound the property of the placeholder is not found and has no default value. 属性不存在
+type ErrPropertyNotFound struct {
+	error
+	Key string // the property key
+}
+
+func (e *ErrPropertyNotFound) Error() string {
+	return fmt.Sprintf("ErrPropertyNotFound : the property '%s' is not found and has no default value", e.Key)
+}
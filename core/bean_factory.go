@@ -1,8 +1,14 @@
 package core
 
 import (
+	"context"
 	"github.com/cutexingluo/go-spring/common/reflect_util"
 	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // FactoryContainer the bean container interface. Encapsulation of Container methods
@@ -27,8 +33,41 @@ type FactoryContainer interface {
 type BeanFactory struct {
 	BeanContainer Container
 	BeanTopo      *parse.BeanTopo
+	// Parent the lookups fall back to the parent factory if the bean is not found in this factory, the beans of this
+	// factory shadow the parent beans of the same name. nil is no parent. 父工厂, 找不到 bean 时从父工厂查找
+	Parent *BeanFactory
 	//BeanInitQueue []string                     // after create initialize
 	BeanChains map[int][]*BeanFilterFunction // bean filter function, can change the bean
+	// Parallelism the max workers to initialize the beans of a topo layer concurrently in BeanInjected, 0 or 1 is serial.
+	// the filters must be safe for concurrent use. 并行初始化的最大协程数
+	Parallelism int
+	// DestroyTimeout the max time to destroy a bean in Close, 0 is no timeout. 销毁单个 bean 的超时时间
+	DestroyTimeout time.Duration
+
+	initOrder []string    // the bean names in the order of BeanInjected, dependencies first
+	closed    atomic.Bool // Close has been called
+
+	ctxLock    sync.Mutex         // guards rootCtx
+	rootCtx    context.Context    // cancelled on shutdown
+	rootCancel context.CancelFunc // cancel rootCtx
+	mainLock   sync.Mutex         // guards mainCount and mainDone
+	mainCount  int                // the running main goroutines started by Go
+	mainDone   chan struct{}      // closed when mainCount drops to 0
+
+	scopeLock   sync.RWMutex            // guards scopes, scopedBeans and scopedTypes
+	scopes      map[string]Scope        // scopeName -> scope
+	scopedBeans map[string]*ScopedBean  // beanName -> scoped bean definition
+	scopedTypes map[string]reflect.Type // beanName -> the ptr type of the scoped bean, got from a raw instance
+	primaries   map[string]bool         // beanName -> is primary
+	aliases     map[string]string       // alias -> beanName or another alias
+	providers   []*beanProvider         // the constructors invoked in BeanCreated
+	earlyBeans  map[string]bool         // the parameter beans of the providers, initialized before the phases
+
+	activeProfiles     []string         // the active profiles set by SetActiveProfiles
+	profilesSet        bool             // SetActiveProfiles has been called
+	propertyResolver   PropertyResolver // used by OnProperty
+	environment        *Environment     // the property sources, registered as a bean
+	conditionalConfigs []*BeanConfig    // the configs evaluated before BeanCreated
 }
 
 func NewBeanFactoryByContainer(container Container) *BeanFactory {
@@ -36,31 +75,88 @@ func NewBeanFactoryByContainer(container Container) *BeanFactory {
 		BeanContainer: container,
 		BeanTopo:      parse.NewBeanTopo(),
 		BeanChains:    make(map[int][]*BeanFilterFunction),
+		scopes:        map[string]Scope{ScopePrototype: &PrototypeScope{}},
+		scopedBeans:   make(map[string]*ScopedBean),
+		scopedTypes:   make(map[string]reflect.Type),
+		primaries:     make(map[string]bool),
+		aliases:       make(map[string]string),
+		earlyBeans:    make(map[string]bool),
+		environment:   NewEnvironment(),
 	}
 }
 
+// NewBeanFactory create a factory with a ConcurrentBeanContainer and an empty Environment, so the command-line arguments
+// of the process (such as the flags of go test) do not leak into its placeholders
 func NewBeanFactory() *BeanFactory {
-	return NewBeanFactoryByContainer(NewBeanContainer())
+	return NewBeanFactoryByContainer(NewConcurrentBeanContainer())
 }
 
-// HasBean checks whether the beanName in the container
+// NewStandardBeanFactory create the factory of the application, such as core.Context and the factory of application.New,
+// its Environment is NewStandardEnvironment. 创建应用的工厂, 使用标准环境
+func NewStandardBeanFactory() *BeanFactory {
+	factory := NewBeanFactory()
+	factory.environment = NewStandardEnvironment()
+	return factory
+}
+
+// NewChildBeanFactory create a child factory of the parent, the lookups fall back to the parent.
+// 创建子工厂, 找不到 bean 时从父工厂查找
+func NewChildBeanFactory(parent *BeanFactory) *BeanFactory {
+	return NewChildBeanFactoryByContainer(parent, NewConcurrentBeanContainer())
+}
+
+// NewChildBeanFactoryByContainer create a child factory of the parent with the container
+func NewChildBeanFactoryByContainer(parent *BeanFactory, container Container) *BeanFactory {
+	factory := NewBeanFactoryByContainer(container)
+	factory.Parent = parent
+	factory.environment = parent.Environment()
+	return factory
+}
+
+// Freeze turns the container read-only if it is a FreezableContainer, it is called after BeanInjected. the mutations of
+// the beans, the scoped beans, the providers and the primary beans return ErrContainerFrozen. 冻结容器
+func (_this *BeanFactory) Freeze() {
+	if container, ok := _this.BeanContainer.(FreezableContainer); ok {
+		container.Freeze()
+	}
+}
+
+// IsFrozen checks whether the container is frozen
+func (_this *BeanFactory) IsFrozen() bool {
+	container, ok := _this.BeanContainer.(FreezableContainer)
+	return ok && container.IsFrozen()
+}
+
+// HasBean checks whether the beanName in the container, or in the parent factory
 func (_this *BeanFactory) HasBean(beanNameOrType string) bool {
-	return _this.BeanContainer.HasBean(beanNameOrType)
+	return _this.HasLocalBean(beanNameOrType) || _this.Parent != nil && _this.Parent.HasBean(beanNameOrType)
+}
+
+// HasLocalBean checks whether the beanName in the container or the scoped beans of this factory, ignoring the parent
+func (_this *BeanFactory) HasLocalBean(beanNameOrType string) bool {
+	beanNameOrType = _this.CanonicalName(beanNameOrType)
+	return _this.BeanContainer.HasBean(beanNameOrType) || _this.IsScopedBean(beanNameOrType)
 }
 
-// HasBeanType checks whether the beanType in the container
+// HasBeanType checks whether the beanType in the container, or in the parent factory
 func (_this *BeanFactory) HasBeanType(beanType string) bool {
-	return _this.BeanContainer.HasBeanType(beanType)
+	return _this.BeanContainer.HasBeanType(beanType) || _this.Parent != nil && _this.Parent.HasBeanType(beanType)
 }
 
-// IsSingleBean checks whether the bean is single
+// IsSingleBean checks whether the bean is single, or it is a single bean of the parent factory not shadowed
 func (_this *BeanFactory) IsSingleBean(beanNameOrType string) bool {
-	return _this.BeanContainer.IsSingleBean(beanNameOrType)
+	if _this.HasLocalBean(beanNameOrType) {
+		return _this.BeanContainer.IsSingleBean(_this.CanonicalName(beanNameOrType))
+	}
+	return _this.Parent != nil && _this.Parent.IsSingleBean(beanNameOrType)
 }
 
-// IsMultiBean checks whether the bean is multiple
+// IsMultiBean checks whether the bean is multiple, or it is a multi bean of the parent factory not shadowed
 func (_this *BeanFactory) IsMultiBean(beanName string) bool {
-	return _this.BeanContainer.IsMultiBean(beanName)
+	if _this.HasLocalBean(beanName) {
+		return _this.BeanContainer.IsMultiBean(_this.CanonicalName(beanName))
+	}
+	return _this.Parent != nil && _this.Parent.IsMultiBean(beanName)
 }
 
 // AddSingleBean add a single bean to the collection
@@ -110,9 +206,9 @@ func (_this *BeanFactory) GetMultiBean(beanName string) any {
 	return ret.Interface()
 }
 
-// GetBean get the bean, you must set  typeName pkg+structName if it is beanType
+// GetBean get the bean, you must set  typeName pkg+structName if it is beanType. scoped beans are got from their scopes
 func (_this *BeanFactory) GetBean(beanNameOrType string) (any, error) {
-	ret, err := _this.BeanContainer.GetBean(beanNameOrType)
+	ret, err := _this.GetBeanValue(beanNameOrType)
 	if ret == nil {
 		return nil, err
 	}
@@ -128,3 +224,200 @@ func (_this *BeanFactory) RemoveSingleBean(beanType string) (bool, error) {
 func (_this *BeanFactory) RemoveMultiBean(beanName string, beanType string) (bool, error) {
 	return _this.BeanContainer.RemoveMultiBean(beanName, beanType)
 }
+
+// ResolveBeanName get the bean name by the bean type. if the type is a single bean, the beanType is returned,
+// otherwise the only multi bean of the type, or the only primary bean if there are several of them.
+// if no bean of this factory matches the type, it is resolved by the parent factory. 根据类型获取唯一的 bean 名称
+func (_this *BeanFactory) ResolveBeanName(beanType reflect.Type) (string, error) {
+	if beanType == nil {
+		return "", &ErrBeanNotFound{BeanName: "<nil>"}
+	}
+	typeName := BeanNameFilter(beanType.String())
+	if _this.BeanContainer.IsSingleBean(typeName) {
+		return typeName, nil
+	}
+	names := _this.localBeanNamesByType(beanType)
+	switch len(names) {
+	case 0:
+		if _this.Parent != nil {
+			return _this.Parent.ResolveBeanName(beanType)
+		}
+		return "", &ErrBeanNotFound{BeanName: typeName}
+	case 1:
+		return names[0], nil
+	default:
+		var primaries []string
+		for _, name := range names {
+			if _this.IsPrimary(name) {
+				primaries = append(primaries, name)
+			}
+		}
+		if len(primaries) == 1 {
+			return primaries[0], nil
+		}
+		candidates := append([]string{}, names...)
+		sort.Strings(candidates)
+		return "", &ErrBeanNotUnique{BeanType: typeName, Candidates: candidates}
+	}
+}
+
+// GetBeanNamesOfType get the names of all beans of the beanType, single or multi, including the beans of the parent
+// factory not shadowed. 获取该类型所有的 bean 名称
+func (_this *BeanFactory) GetBeanNamesOfType(beanType string) []string {
+	names := _this.localBeanNamesOfType(beanType)
+	if _this.Parent != nil {
+		names = _this.appendParentBeanNames(names, _this.Parent.GetBeanNamesOfType(beanType))
+	}
+	return names
+}
+
+// appendParentBeanNames append the parent bean names not shadowed by the beans of this factory
+func (_this *BeanFactory) appendParentBeanNames(names []string, parentNames []string) []string {
+	for _, name := range parentNames {
+		if !_this.HasLocalBean(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// localBeanNamesOfType get the names of the beans of the beanType in this factory, the scoped beans come last
+func (_this *BeanFactory) localBeanNamesOfType(beanType string) []string {
+	beanType = BeanNameFilter(beanType)
+	if beanType == "" {
+		return nil
+	}
+	if _this.BeanContainer.IsSingleBean(beanType) {
+		return []string{beanType}
+	}
+	var names []string
+	for _, name := range _this.BeanContainer.GetMultiBeanNames(beanType) {
+		if _this.BeanContainer.IsMultiBean(name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range _this.scopedBeanNames() {
+		if scopedType := _this.scopedBeanType(name); scopedType != nil && BeanNameFilter(scopedType.String()) == beanType {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetBeanValue get the bean value from the container or its scope, then from the parent factory,
+// the alias is resolved to the bean name. return ErrBeanNotFound if it is not found
+func (_this *BeanFactory) GetBeanValue(beanNameOrType string) (*reflect.Value, error) {
+	if _this.IsAlias(beanNameOrType) {
+		beanNameOrType = _this.CanonicalName(beanNameOrType)
+	}
+	ret, err := _this.BeanContainer.GetBean(beanNameOrType)
+	if err == nil && ret != nil {
+		return ret, nil
+	}
+	beanName := BeanNameFilter(beanNameOrType)
+	if scopedBean := _this.getScopedDefinition(beanName); scopedBean != nil {
+		return _this.getScopedBean(beanName, scopedBean)
+	}
+	if _this.Parent != nil {
+		return _this.Parent.GetBeanValue(beanNameOrType)
+	}
+	return nil, &ErrBeanNotFound{BeanName: beanName}
+}
+
+// GetBeanNamesOfInterface get the names of all beans implementing the interface, including the beans of the parent
+// factory not shadowed, sorted by name. 获取实现该接口的所有 bean 名称
+func (_this *BeanFactory) GetBeanNamesOfInterface(interfaceType reflect.Type) []string {
+	names := _this.localBeanNamesOfInterface(interfaceType)
+	if _this.Parent != nil {
+		names = _this.appendParentBeanNames(names, _this.Parent.GetBeanNamesOfInterface(interfaceType))
+		sort.Strings(names)
+	}
+	return names
+}
+
+// localBeanNamesOfInterface get the names of the beans implementing the interface in this factory, including the
+// scoped beans, sorted by name
+func (_this *BeanFactory) localBeanNamesOfInterface(interfaceType reflect.Type) []string {
+	if interfaceType == nil || interfaceType.Kind() != reflect.Interface {
+		return nil
+	}
+	var names []string
+	for _, name := range _this.BeanContainer.GetAllBeanNames() {
+		bean, err := _this.BeanContainer.GetBean(name)
+		if err != nil || bean == nil {
+			continue
+		}
+		if reflect_util.GetPtrByValue(bean).Type().Implements(interfaceType) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range _this.scopedBeanNames() {
+		if scopedType := _this.scopedBeanType(name); scopedType != nil && scopedType.Implements(interfaceType) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetBeansOfInterface get all beans implementing the interface, map[beanName]bean. 获取实现该接口的所有 bean
+func (_this *BeanFactory) GetBeansOfInterface(interfaceType reflect.Type) map[string]any {
+	names := _this.GetBeanNamesOfInterface(interfaceType)
+	beans := make(map[string]any, len(names))
+	for _, name := range names {
+		if bean, err := _this.GetBeanValue(name); err == nil && bean != nil {
+			beans[name] = reflect_util.GetPtrByValue(bean).Interface()
+		}
+	}
+	return beans
+}
+
+// GetBeanNamesByType get the names of the beans of the type, or implementing the type if it is an interface.
+// 获取该类型的所有 bean 名称, 如果是接口则获取实现该接口的所有 bean 名称
+func (_this *BeanFactory) GetBeanNamesByType(beanType reflect.Type) []string {
+	if beanType.Kind() == reflect.Interface {
+		return _this.GetBeanNamesOfInterface(beanType)
+	}
+	return _this.GetBeanNamesOfType(beanType.String())
+}
+
+// localBeanNamesByType like GetBeanNamesByType, ignoring the parent factory
+func (_this *BeanFactory) localBeanNamesByType(beanType reflect.Type) []string {
+	if beanType.Kind() == reflect.Interface {
+		return _this.localBeanNamesOfInterface(beanType)
+	}
+	return _this.localBeanNamesOfType(beanType.String())
+}
+
+// SortBeanNames sort the bean names by core.Ordered, the smaller order comes first,
+// the beans not implementing core.Ordered come last, then sorted by name. 根据 Ordered 排序
+func (_this *BeanFactory) SortBeanNames(beanNames []string) []string {
+	type orderedName struct {
+		name    string
+		ordered bool
+		order   int
+	}
+	items := make([]orderedName, len(beanNames))
+	for i, name := range beanNames {
+		items[i].name = name
+		if bean, err := _this.GetBeanValue(name); err == nil {
+			if ordered, ok := reflect_util.GetPtrByValue(bean).Interface().(Ordered); ok {
+				items[i].ordered, items[i].order = true, ordered.Order()
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].ordered != items[j].ordered {
+			return items[i].ordered
+		}
+		if items[i].order != items[j].order {
+			return items[i].order < items[j].order
+		}
+		return items[i].name < items[j].name
+	})
+	sorted := make([]string, len(items))
+	for i, item := range items {
+		sorted[i] = item.name
+	}
+	return sorted
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"github.com/cutexingluo/go-spring/common/reflect_util"
+	"reflect"
+)
+
+// Get get the bean by the type T, T can be ptr type, struct type (it will copy) or interface type. 根据泛型类型获取 bean
+//
+// like factory.GetBean("pkg.Struct"), but the beanType is derived from T, so no type assertion is needed.
+// if there are several multi beans of the type and none or several of them are primary, ErrBeanNotUnique is returned,
+// use GetQualified or GetNamed instead.
+func Get[T any](factory *BeanFactory) (T, error) {
+	var zero T
+	beanName, err := factory.ResolveBeanName(TypeOf[T]())
+	if err != nil {
+		return zero, err
+	}
+	return GetNamed[T](factory, beanName)
+}
+
+// GetNamed get the bean by the beanName or beanType, and convert it to T. 根据名称获取 bean 并转为 T 类型
+func GetNamed[T any](factory *BeanFactory, beanNameOrType string) (T, error) {
+	var zero T
+	bean, err := factory.GetBeanValue(beanNameOrType)
+	if err != nil {
+		return zero, err
+	}
+	return castBean[T](BeanNameFilter(beanNameOrType), bean)
+}
+
+// GetQualified get the bean of the type T whose name is the qualifier. 根据类型和限定名获取 bean
+func GetQualified[T any](factory *BeanFactory, qualifier string) (T, error) {
+	var zero T
+	beanName, err := factory.ResolveQualifiedBeanName(TypeOf[T](), qualifier)
+	if err != nil {
+		return zero, err
+	}
+	return GetNamed[T](factory, beanName)
+}
+
+// MustGet get the bean by the type T, panic if it failed. 获取 bean, 失败则 panic
+func MustGet[T any](factory *BeanFactory) T {
+	bean, err := Get[T](factory)
+	if err != nil {
+		panic(err)
+	}
+	return bean
+}
+
+// GetAll get all beans of the type T, single or multi. the multi beans are in the order of registration.
+// if T is an interface type, all beans implementing it are returned, sorted by name.
+// 获取该类型的所有 bean
+func GetAll[T any](factory *BeanFactory) ([]T, error) {
+	names := factory.GetBeanNamesByType(TypeOf[T]())
+	beans := make([]T, 0, len(names))
+	for _, name := range names {
+		bean, err := GetNamed[T](factory, name)
+		if err != nil {
+			return nil, err
+		}
+		beans = append(beans, bean)
+	}
+	return beans, nil
+}
+
+// TypeOf get the reflect.Type of T, T can be an interface type. 获取泛型的类型
+func TypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// castBean convert the bean in the container (ptr type) to T
+func castBean[T any](beanName string, bean *reflect.Value) (T, error) {
+	var zero T
+	requiredType := TypeOf[T]()
+	var ret *reflect.Value
+	switch requiredType.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		ret = reflect_util.GetPtrByValue(bean)
+	default: // copy 一份
+		ret = reflect_util.GetStructByValue(bean)
+	}
+	if v, ok := ret.Interface().(T); ok {
+		return v, nil
+	}
+	return zero, &ErrBeanTypeMismatch{
+		BeanName:     beanName,
+		RequiredType: requiredType.String(),
+		ActualType:   bean.Type().String(),
+	}
+}
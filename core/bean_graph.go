@@ -0,0 +1,140 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the kinds of the nodes in BeanGraph
+const (
+	SingleBeanKind  = "single"
+	MultiBeanKind   = "multi"
+	UnknownBeanKind = "unknown" // in the topo but not in the factory
+)
+
+// BeanGraphNode the bean in the dependency graph
+type BeanGraphNode struct {
+	Name string `json:"name"` // bean name, it is the bean type for single beans
+	Type string `json:"type"` // bean type, empty for the scoped beans
+	Kind string `json:"kind"` // single, multi, unknown or the scope name of the scoped beans
+}
+
+// BeanGraphEdge the dependency in the dependency graph, From depends on To
+type BeanGraphEdge struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Fields []string `json:"fields,omitempty"` // the struct fields and tags that created the edge
+}
+
+// BeanGraph the dependency graph of the beans, nodes and edges are sorted so that the output can be diffed.
+// bean 依赖关系图
+type BeanGraph struct {
+	Nodes []BeanGraphNode `json:"nodes"`
+	Edges []BeanGraphEdge `json:"edges"`
+}
+
+// DependencyGraph get the dependency graph from the container and the BeanTopo, the edges are recorded in TagInitialized.
+// 获取 bean 依赖关系图
+func (_this *BeanFactory) DependencyGraph() *BeanGraph {
+	graph := &BeanGraph{Nodes: []BeanGraphNode{}, Edges: []BeanGraphEdge{}}
+	nodes := make(map[string]BeanGraphNode)
+	for _, name := range _this.BeanContainer.GetAllBeanNames() {
+		node := BeanGraphNode{Name: name, Type: _this.BeanContainer.GetType(name), Kind: MultiBeanKind}
+		if _this.BeanContainer.IsSingleBean(name) {
+			node.Kind = SingleBeanKind
+		}
+		nodes[name] = node
+	}
+	for name, scopedBean := range _this.scopedBeans {
+		nodes[name] = BeanGraphNode{Name: name, Kind: scopedBean.Scope}
+	}
+	for _, edge := range _this.BeanTopo.GetEdges() {
+		for _, name := range []string{edge.Src, edge.To} {
+			if _, ok := nodes[name]; !ok {
+				nodes[name] = BeanGraphNode{Name: name, Kind: UnknownBeanKind}
+			}
+		}
+		graph.Edges = append(graph.Edges, BeanGraphEdge{From: edge.Src, To: edge.To, Fields: edge.Reasons})
+	}
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Name < graph.Nodes[j].Name
+	})
+	return graph
+}
+
+// ExportDOT export the dependency graph as Graphviz DOT
+func (_this *BeanFactory) ExportDOT() string {
+	return _this.DependencyGraph().DOT()
+}
+
+// ExportMermaid export the dependency graph as Mermaid flowchart
+func (_this *BeanFactory) ExportMermaid() string {
+	return _this.DependencyGraph().Mermaid()
+}
+
+// ExportJSON export the dependency graph as indented JSON
+func (_this *BeanFactory) ExportJSON() ([]byte, error) {
+	return _this.DependencyGraph().JSON()
+}
+
+// DOT returns the graph in Graphviz DOT, such as `dot -Tsvg beans.dot -o beans.svg`
+func (_this *BeanGraph) DOT() string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+	builder := strings.Builder{}
+	builder.WriteString("digraph beans {\n")
+	builder.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range _this.Nodes {
+		builder.WriteString(fmt.Sprintf("  %s [label=%s];\n", quote(node.Name), quote(node.label())))
+	}
+	for _, edge := range _this.Edges {
+		builder.WriteString(fmt.Sprintf("  %s -> %s", quote(edge.From), quote(edge.To)))
+		if len(edge.Fields) > 0 {
+			builder.WriteString(fmt.Sprintf(" [label=%s]", quote(strings.Join(edge.Fields, "\n"))))
+		}
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Mermaid returns the graph in Mermaid flowchart, the node ids are n0, n1 ... in the order of Nodes
+func (_this *BeanGraph) Mermaid() string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(s) + `"`
+	}
+	ids := make(map[string]string, len(_this.Nodes))
+	builder := strings.Builder{}
+	builder.WriteString("flowchart LR\n")
+	for i, node := range _this.Nodes {
+		ids[node.Name] = fmt.Sprintf("n%d", i)
+		builder.WriteString(fmt.Sprintf("  %s[%s]\n", ids[node.Name], quote(node.label())))
+	}
+	for _, edge := range _this.Edges {
+		if len(edge.Fields) > 0 {
+			builder.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", ids[edge.From], quote(strings.Join(edge.Fields, "\n")), ids[edge.To]))
+		} else {
+			builder.WriteString(fmt.Sprintf("  %s --> %s\n", ids[edge.From], ids[edge.To]))
+		}
+	}
+	return builder.String()
+}
+
+// JSON returns the graph in indented JSON
+func (_this *BeanGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(_this, "", "  ")
+}
+
+// label returns the name, the type (if it is not the name) and the kind
+func (_this *BeanGraphNode) label() string {
+	if _this.Type == "" || _this.Type == _this.Name {
+		return fmt.Sprintf("%s\n(%s)", _this.Name, _this.Kind)
+	}
+	return fmt.Sprintf("%s\n%s (%s)", _this.Name, _this.Type, _this.Kind)
+}
@@ -0,0 +1,109 @@
+package core_test
+
+import (
+	"encoding/json"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"testing"
+)
+
+type graphDB struct{}
+
+type graphRepo struct {
+	DB *graphDB `bean:"db"`
+}
+
+type graphService struct {
+	Repo *graphRepo `bean:"core_test.graphRepo"`
+}
+
+// newGraphFactory the factory of service -> repo -> db, db is a multi bean
+func newGraphFactory(t *testing.T) *core.BeanFactory {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&graphService{}, &graphRepo{}},
+		MultiBeans:  map[string]any{"db": &graphDB{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunBeanCreated(); err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunTagInitialized(); err != nil {
+		t.Fatal(err)
+	}
+	return factory
+}
+
+func TestExportDOT(t *testing.T) {
+	want := `digraph beans {
+  rankdir=LR;
+  node [shape=box];
+  "core.Environment" [label="core.Environment\n(single)"];
+  "core_test.graphRepo" [label="core_test.graphRepo\n(single)"];
+  "core_test.graphService" [label="core_test.graphService\n(single)"];
+  "db" [label="db\ncore_test.graphDB (multi)"];
+  "core_test.graphRepo" -> "db" [label="field core_test.graphRepo.DB ` + "`bean:\\\"db\\\"`" + `"];
+  "core_test.graphService" -> "core_test.graphRepo" [label="field core_test.graphService.Repo ` +
+		"`bean:\\\"core_test.graphRepo\\\"`" + `"];
+}
+`
+	if got := newGraphFactory(t).ExportDOT(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportMermaid(t *testing.T) {
+	want := `flowchart LR
+  n0["core.Environment<br/>(single)"]
+  n1["core_test.graphRepo<br/>(single)"]
+  n2["core_test.graphService<br/>(single)"]
+  n3["db<br/>core_test.graphDB (multi)"]
+  n1 -->|"field core_test.graphRepo.DB ` + "`bean:#quot;db#quot;`" + `"| n3
+  n2 -->|"field core_test.graphService.Repo ` + "`bean:#quot;core_test.graphRepo#quot;`" + `"| n1
+`
+	if got := newGraphFactory(t).ExportMermaid(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	data, err := newGraphFactory(t).ExportJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got core.BeanGraph
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := core.BeanGraph{
+		Nodes: []core.BeanGraphNode{
+			{Name: "core.Environment", Type: "core.Environment", Kind: core.SingleBeanKind},
+			{Name: "core_test.graphRepo", Type: "core_test.graphRepo", Kind: core.SingleBeanKind},
+			{Name: "core_test.graphService", Type: "core_test.graphService", Kind: core.SingleBeanKind},
+			{Name: "db", Type: "core_test.graphDB", Kind: core.MultiBeanKind},
+		},
+		Edges: []core.BeanGraphEdge{
+			{From: "core_test.graphRepo", To: "db", Fields: []string{"field core_test.graphRepo.DB `bean:\"db\"`"}},
+			{From: "core_test.graphService", To: "core_test.graphRepo",
+				Fields: []string{"field core_test.graphService.Repo `bean:\"core_test.graphRepo\"`"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestDependencyGraphUnknown the bean only in the topo is an unknown node
+func TestDependencyGraphUnknown(t *testing.T) {
+	factory := newGraphFactory(t)
+	factory.BeanTopo.Add("core_test.graphService", "missing")
+	graph := factory.DependencyGraph()
+	if node := graph.Nodes[len(graph.Nodes)-1]; node != (core.BeanGraphNode{Name: "missing", Kind: core.UnknownBeanKind}) {
+		t.Errorf("got the last node %+v, want the unknown node", node)
+	}
+}
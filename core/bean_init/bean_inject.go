@@ -1,33 +1,210 @@
 package bean_init
 
 import (
+	"errors"
+	"fmt"
 	"github.com/cutexingluo/go-spring/common/reflect_util"
+	"github.com/cutexingluo/go-spring/common/se/slice_util"
 	"github.com/cutexingluo/go-spring/core"
 	"github.com/cutexingluo/go-spring/core/parse"
 	"reflect"
+	"strings"
 )
 
-// BeanInject autowired the bean . tag `bean:""` 利用 tag 注入
+var lazyBinderType = reflect.TypeOf((*core.LazyBinder)(nil)).Elem()
+
+// BeanInject autowired the bean of core.Context . tag `bean:""` 利用 tag 注入
 func BeanInject(val *reflect.Value) (ret *reflect.Value, err error) {
-	return parse.InitByTag(val, &parse.TagParser{ParseFunc: ParseBean})
+	return BeanInjectWith(core.Context, val)
+}
+
+// BeanInjectWith autowired the bean of the factory . tag `bean:""` 利用 tag 注入指定工厂的 bean
+func BeanInjectWith(factory *core.BeanFactory, val *reflect.Value) (ret *reflect.Value, err error) {
+	return parse.InitByTag(val, NewBeanParser(factory))
+}
+
+// NewBeanParser the tag parser injecting the beans of the factory, like ParseBean
+func NewBeanParser(factory *core.BeanFactory) *parse.TagParser {
+	return &parse.TagParser{
+		ParseFunc: func(srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) error {
+			return parseBean(factory, srcVal, kind, srcField, structField)
+		},
+	}
 }
 
-// ParseBean  解析某个对象的Bean
+// ParseBean  解析某个对象的Bean, the beans are in core.Context
 func ParseBean(srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) (err error) {
-	if parse.IsSupportBean(*kind) { // Bean 类型
-		tagValue := structField.Tag.Get("bean")
-		if tagValue == "" {
-			return nil
+	return parseBean(core.Context, srcVal, kind, srcField, structField)
+}
+
+// parseBean 解析某个对象的Bean
+func parseBean(factory *core.BeanFactory, srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) (err error) {
+	if beanTag, ok := ParseBeanTag(structField); ok && beanTag.Lazy { // 延迟注入
+		return injectLazy(factory, srcVal, srcField, structField, beanTag)
+	} else if parse.IsSupportBean(*kind) || *kind == reflect.Interface { // Bean 类型
+		beanName, ok, err := beanNameOf(factory, srcVal, structField)
+		if err != nil || !ok {
+			return err
 		}
-		bean, err := core.Context.BeanContainer.GetBean(tagValue) // 获取bean
+		bean, err := factory.GetBeanValue(beanName) // 获取bean
 		if err != nil || bean == nil {
+			return injectFieldError(srcVal, structField, err)
+		}
+		value, err := convertBean(beanName, bean, structField.Type)
+		if err != nil {
+			return injectFieldError(srcVal, structField, err)
+		}
+		srcField.Set(value)
+	} else if beanTag, ok := ParseBeanTag(structField); ok && beanTag.All && parse.IsSupportComposite(*kind) { // bean 集合
+		beanNames, err := collectBeanNames(factory, srcVal, structField, beanTag)
+		if err != nil {
 			return err
 		}
-		if *kind == reflect.Ptr { //指针则赋值
-			srcField.Set(*reflect_util.GetPtrByValue(bean))
-		} else { // copy 一份
-			srcField.Set(*reflect_util.GetStructByValue(bean))
+		elemType := structField.Type.Elem()
+		var collection reflect.Value
+		if *kind == reflect.Slice {
+			collection = reflect.MakeSlice(structField.Type, 0, len(beanNames))
+		} else {
+			collection = reflect.MakeMapWithSize(structField.Type, len(beanNames))
+		}
+		for _, beanName := range beanNames {
+			bean, err := factory.GetBeanValue(beanName)
+			if err != nil {
+				return injectFieldError(srcVal, structField, err)
+			}
+			value, err := convertBean(beanName, bean, elemType)
+			if err != nil {
+				return injectFieldError(srcVal, structField, err)
+			}
+			if *kind == reflect.Slice {
+				collection = reflect.Append(collection, value)
+			} else {
+				collection.SetMapIndex(reflect.ValueOf(beanName).Convert(structField.Type.Key()), value)
+			}
 		}
+		srcField.Set(collection)
+	}
+	return nil
+}
+
+// injectFieldError wrap the error of injecting the field, nil if err is nil
+func injectFieldError(srcVal *reflect.Value, structField *reflect.StructField, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to inject the field '%s' of '%s': %w", structField.Name, srcVal.Type(), err)
+}
+
+// injectLazy bind the core.Lazy[T] or *core.Lazy[T] field, the bean is resolved on first use
+func injectLazy(factory *core.BeanFactory, srcVal *reflect.Value, srcField *reflect.Value, structField *reflect.StructField, beanTag *BeanTag) error {
+	lazyType := structField.Type
+	if lazyType.Kind() == reflect.Ptr {
+		lazyType = lazyType.Elem()
+	}
+	if lazyType.Kind() != reflect.Struct || !reflect.PtrTo(lazyType).Implements(lazyBinderType) {
+		return fmt.Errorf("the lazy field '%s' of '%s' must be core.Lazy[T] or *core.Lazy[T]", structField.Name, srcVal.Type())
+	}
+	var lazy reflect.Value
+	if structField.Type.Kind() == reflect.Ptr {
+		if srcField.IsNil() {
+			srcField.Set(reflect.New(lazyType))
+		}
+		lazy = *srcField
+	} else {
+		lazy = srcField.Addr()
+	}
+	beanName := beanTag.Qualifier
+	if beanName == "" {
+		beanName = beanTag.Name
 	}
+	lazy.Interface().(core.LazyBinder).BindLazy(factory, beanName)
 	return nil
 }
+
+// convertBean convert the bean (ptr type) to the target type, ptr and interface are assigned, struct is copied
+func convertBean(beanName string, bean *reflect.Value, targetType reflect.Type) (reflect.Value, error) {
+	if targetType.Kind() != reflect.Interface && !reflect_util.TypeEquals(bean.Type(), targetType) {
+		return reflect.Value{}, &core.ErrBeanTypeMismatch{BeanName: beanName, RequiredType: targetType.String(), ActualType: bean.Type().String()}
+	}
+	switch targetType.Kind() {
+	case reflect.Ptr: //指针则赋值
+		return *reflect_util.GetPtrByValue(bean), nil
+	case reflect.Interface: // 接口则赋值指针
+		ptr := reflect_util.GetPtrByValue(bean)
+		if !ptr.Type().Implements(targetType) {
+			return reflect.Value{}, &core.ErrBeanTypeMismatch{BeanName: beanName, RequiredType: targetType.String(), ActualType: ptr.Type().String()}
+		}
+		return *ptr, nil
+	default: // copy 一份
+		return *reflect_util.GetStructByValue(bean), nil
+	}
+}
+
+// collectBeanNames get the names of all beans injected into the slice or map field, ordered by the order tag and core.Ordered
+// 获取注入集合的所有 bean 名称
+func collectBeanNames(factory *core.BeanFactory, srcVal *reflect.Value, structField *reflect.StructField, beanTag *BeanTag) ([]string, error) {
+	fieldType := structField.Type
+	elemType := fieldType.Elem()
+	if fieldType.Kind() == reflect.Map && fieldType.Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("the field '%s' of '%s' must be a map with string keys", structField.Name, srcVal.Type())
+	}
+	if !parse.IsSupportBean(elemType.Kind()) && elemType.Kind() != reflect.Interface {
+		return nil, fmt.Errorf("the elements of the field '%s' of '%s' must be ptr, struct or interface beans", structField.Name, srcVal.Type())
+	}
+	var beanNames []string
+	if beanType := core.BeanNameFilter(beanTag.Name); beanType != "" {
+		beanNames = factory.GetBeanNamesOfType(beanType)
+	} else {
+		beanNames = factory.GetBeanNamesByType(elemType)
+	}
+	beanNames = factory.SortBeanNames(beanNames)
+
+	orderValue := strings.TrimSpace(structField.Tag.Get(OrderValue))
+	if orderValue == "" {
+		return beanNames, nil
+	}
+	sorted := make([]string, 0, len(beanNames))
+	for _, name := range strings.Split(orderValue, ",") {
+		name = factory.CanonicalName(name)
+		if slice_util.ContainsString(beanNames, name) && !slice_util.ContainsString(sorted, name) {
+			sorted = append(sorted, name)
+		}
+	}
+	for _, name := range beanNames {
+		if !slice_util.ContainsString(sorted, name) {
+			sorted = append(sorted, name)
+		}
+	}
+	return sorted, nil
+}
+
+// beanNameOf get the bean name from the bean tag, ok is false if the field is not injected.
+// the qualifier tag chooses the bean among the beans of the field type. ok is false if the bean is optional and missing.
+// if the field is autowired (`autowired:"true"` or `bean:",auto"`), or it is an interface and the name is empty (`bean:""`),
+// the bean is resolved by the field type: the single bean of the type, or the only multi bean of the type.
+// 获取字段需要注入的 bean 名称
+func beanNameOf(factory *core.BeanFactory, srcVal *reflect.Value, structField *reflect.StructField) (beanName string, ok bool, err error) {
+	beanTag, ok := ParseBeanTag(structField)
+	if !ok || beanTag.Lazy { // lazy beans are resolved on first use, without a dependency
+		return "", false, nil
+	}
+	if beanTag.Qualifier != "" {
+		beanName, err = factory.ResolveQualifiedBeanName(structField.Type, beanTag.Qualifier)
+	} else if beanName = factory.CanonicalName(beanTag.Name); beanName != "" {
+		if beanTag.Optional && !factory.HasBean(beanName) {
+			return "", false, nil
+		}
+		return beanName, true, nil
+	} else if beanTag.Auto || structField.Type.Kind() == reflect.Interface {
+		beanName, err = factory.ResolveBeanName(structField.Type)
+	} else {
+		return "", false, nil
+	}
+	var notFound *core.ErrBeanNotFound
+	if err != nil && beanTag.Optional && errors.As(err, &notFound) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, injectFieldError(srcVal, structField, err)
+	}
+	return beanName, true, nil
+}
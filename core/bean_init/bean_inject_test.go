@@ -0,0 +1,226 @@
+package bean_init_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/bean_init"
+	"reflect"
+	"testing"
+)
+
+type injectRunner interface {
+	Run() string
+}
+
+type injectTask struct {
+	name  string
+	order int
+}
+
+func (t *injectTask) Run() string {
+	return t.name
+}
+
+func (t *injectTask) Order() int {
+	return t.order
+}
+
+// newTaskFactory the factory with the tasks t1, t2 and t3, in the order t2, t3, t1
+func newTaskFactory(t *testing.T) *core.BeanFactory {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	for name, order := range map[string]int{"t1": 3, "t2": 1, "t3": 2} {
+		if _, err := factory.AddMultiBean(name, &injectTask{name: name, order: order}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return factory
+}
+
+// inject inject the fields of the ptr by the factory
+func inject(factory *core.BeanFactory, ptr any) error {
+	value := reflect.ValueOf(ptr)
+	_, err := bean_init.BeanInjectWith(factory, &value)
+	return err
+}
+
+// taskNames the names of the tasks in order
+func taskNames[T injectRunner](tasks []T) []string {
+	names := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		names = append(names, task.Run())
+	}
+	return names
+}
+
+// TestInjectAll the all option injects the beans of the type in the order of core.Ordered and the order tag
+func TestInjectAll(t *testing.T) {
+	var target struct {
+		Tasks     []*injectTask           `bean:",all"`
+		Runners   []injectRunner          `bean:",all"`
+		ByType    []*injectTask           `bean:"bean_init_test.injectTask,all"`
+		Ordered   []injectRunner          `bean:",all" order:"t1,missing"`
+		TaskMap   map[string]*injectTask  `bean:",all"`
+		RunnerMap map[string]injectRunner `bean:",all"`
+	}
+	factory := newTaskFactory(t)
+	if err := inject(factory, &target); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"t2", "t3", "t1"}
+	for name, got := range map[string][]string{
+		"Tasks": taskNames(target.Tasks), "Runners": taskNames(target.Runners), "ByType": taskNames(target.ByType),
+	} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got the %s %v, want %v", name, got, want)
+		}
+	}
+	if got := taskNames(target.Ordered); !reflect.DeepEqual(got, []string{"t1", "t2", "t3"}) {
+		t.Errorf("got the ordered %v, want t1 first", got)
+	}
+	if len(target.TaskMap) != 3 || target.TaskMap["t2"].Run() != "t2" || len(target.RunnerMap) != 3 {
+		t.Errorf("got the maps %v and %v", target.TaskMap, target.RunnerMap)
+	}
+
+	var invalid struct {
+		Tasks map[int]*injectTask `bean:",all"`
+	}
+	if err := inject(factory, &invalid); err == nil {
+		t.Error("no error for the map without string keys")
+	}
+}
+
+// TestInjectPrimary the primary bean is chosen by type, the qualifier chooses another one
+func TestInjectPrimary(t *testing.T) {
+	factory := newTaskFactory(t)
+	var target struct {
+		Task   *injectTask  `bean:",auto"`
+		Runner injectRunner `bean:""`
+	}
+	var notUnique *core.ErrBeanNotUnique
+	if err := inject(factory, &target); !errors.As(err, &notUnique) || len(notUnique.Candidates) != 3 {
+		t.Fatalf("got the error %v, want ErrBeanNotUnique of the 3 tasks", err)
+	}
+
+	if err := factory.SetPrimary("t3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := inject(factory, &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.Task.Run() != "t3" || target.Runner.Run() != "t3" {
+		t.Errorf("got %s and %s, want the primary t3", target.Task.Run(), target.Runner.Run())
+	}
+
+	var qualified struct {
+		Task   *injectTask  `qualifier:"t1"`
+		Runner injectRunner `bean:",auto" qualifier:"t2"`
+	}
+	if err := inject(factory, &qualified); err != nil {
+		t.Fatal(err)
+	}
+	if qualified.Task.Run() != "t1" || qualified.Runner.Run() != "t2" {
+		t.Errorf("got %s and %s, want the qualifiers t1 and t2", qualified.Task.Run(), qualified.Runner.Run())
+	}
+
+	if err := factory.SetPrimary("missing"); err == nil {
+		t.Error("no error for the primary bean not found")
+	}
+	var missing struct {
+		Task *injectTask `qualifier:"missing"`
+	}
+	var notFound *core.ErrBeanNotFound
+	if err := inject(factory, &missing); !errors.As(err, &notFound) {
+		t.Errorf("got the error %v, want ErrBeanNotFound", err)
+	}
+	if _, err := factory.AddSingleBean(&injectOther{}); err != nil {
+		t.Fatal(err)
+	}
+	var mismatch struct {
+		Task *injectTask `qualifier:"bean_init_test.injectOther"`
+	}
+	var typeMismatch *core.ErrBeanTypeMismatch
+	if err := inject(factory, &mismatch); !errors.As(err, &typeMismatch) {
+		t.Errorf("got the error %v, want ErrBeanTypeMismatch", err)
+	}
+}
+
+type injectOther struct{}
+
+// TestInjectOptional the optional field is left nil if the bean is missing
+func TestInjectOptional(t *testing.T) {
+	factory := core.NewBeanFactory()
+	var target struct {
+		ByName    *injectTask  `bean:"missing,optional"`
+		ByType    *injectTask  `bean:",auto,optional"`
+		Qualified injectRunner `bean:",optional" qualifier:"missing"`
+	}
+	if err := inject(factory, &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.ByName != nil || target.ByType != nil || target.Qualified != nil {
+		t.Errorf("got %+v, want the nil fields", target)
+	}
+
+	var required struct {
+		Task *injectTask `bean:",auto"`
+	}
+	var notFound *core.ErrBeanNotFound
+	if err := inject(factory, &required); !errors.As(err, &notFound) {
+		t.Errorf("got the error %v, want ErrBeanNotFound", err)
+	}
+
+	task := &injectTask{name: "found"}
+	if _, err := factory.AddSingleBean(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := inject(factory, &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.ByType != task {
+		t.Error("the optional field is not injected when the bean exists")
+	}
+}
+
+// TestInjectLazy the lazy field is resolved on the first Get, the error is not cached
+func TestInjectLazy(t *testing.T) {
+	factory := core.NewBeanFactory()
+	var target struct {
+		ByName core.Lazy[*injectTask]  `bean:"task,lazy"`
+		ByType *core.Lazy[*injectTask] `bean:",lazy"`
+	}
+	if err := inject(factory, &target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.ByName.Get(); err == nil {
+		t.Error("no error before the bean is added")
+	}
+	if _, err := target.ByType.Get(); err == nil {
+		t.Error("no error before the bean is added")
+	}
+
+	task := &injectTask{name: "task"}
+	if _, err := factory.AddMultiBean("task", task); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := target.ByName.Get(); err != nil || got != task {
+		t.Errorf("got %v and the error %v, want the bean added after the failed Get", got, err)
+	}
+	if got := target.ByType.MustGet(); got != task {
+		t.Errorf("got %v by type, want the bean", got)
+	}
+
+	if removed, err := factory.RemoveMultiBean("task", "bean_init_test.injectTask"); !removed || err != nil {
+		t.Fatalf("the bean is not removed: %v", err)
+	}
+	if got, err := target.ByName.Get(); err != nil || got != task {
+		t.Errorf("got %v and the error %v, want the resolved bean kept", got, err)
+	}
+
+	var invalid struct {
+		Task *injectTask `bean:"task,lazy"`
+	}
+	if err := inject(factory, &invalid); err == nil {
+		t.Error("no error for the lazy field which is not core.Lazy")
+	}
+}
@@ -0,0 +1,65 @@
+package bean_init
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	AutowiredValue = "autowired" // autowired by the field type if it is true.  按字段类型自动注入
+	OrderValue     = "order"     // the bean names come first in the collection, such as `order:"task2,task1"`.  集合中优先的 bean 名称
+	QualifierValue = "qualifier" // the name of the bean among the beans of the field type.  限定的 bean 名称
+)
+
+// the options of the bean tag, such as `bean:"pkg.Type,auto"`.  bean tag 的选项
+const (
+	AutoOption     = "auto"     // autowired by the field type, the name is ignored.  按字段类型自动注入
+	AllOption      = "all"      // inject all beans of the type into the slice or map[string] field.  注入该类型的所有 bean
+	OptionalOption = "optional" // leave the field nil if the bean is not in the container.  bean 不存在时不注入
+	LazyOption     = "lazy"     // inject a core.Lazy[T] resolved on first use, without a dependency.  延迟注入
+)
+
+// BeanTag the parsed bean tag of a field.  解析后的 bean tag
+type BeanTag struct {
+	Name string // bean name or bean type, it is empty if the bean is autowired by type
+	Auto bool   // autowired by the field type.  按类型注入
+	All  bool   // all beans of the type, the name is the bean type.  注入该类型的所有 bean
+
+	Optional bool // leave the field nil if the bean is not in the container.  bean 不存在时不注入
+	Lazy     bool // inject a core.Lazy[T] resolved on first use.  延迟注入
+
+	Qualifier string // the name of the bean among the beans of the field type.  限定的 bean 名称
+}
+
+// ParseBeanTag parse the bean tag `bean:"name,option..."`, the autowired tag `autowired:"true"` and the qualifier tag
+// `qualifier:"name"` of the field, ok is false if the field has none of them.  解析字段的 bean tag
+func ParseBeanTag(structField *reflect.StructField) (beanTag *BeanTag, ok bool) {
+	tagValue, hasBean := structField.Tag.Lookup(BeanValue)
+	autowired, hasAutowired := structField.Tag.Lookup(AutowiredValue)
+	qualifier := strings.TrimSpace(structField.Tag.Get(QualifierValue))
+	if !hasBean && !hasAutowired && qualifier == "" {
+		return nil, false
+	}
+	beanTag = &BeanTag{Qualifier: qualifier}
+	if hasAutowired {
+		beanTag.Auto, _ = strconv.ParseBool(strings.TrimSpace(autowired)) // if error pass
+	}
+	items := strings.Split(tagValue, ",")
+	for _, option := range items[1:] {
+		switch strings.TrimSpace(option) {
+		case AutoOption:
+			beanTag.Auto = true
+		case AllOption:
+			beanTag.All = true
+		case OptionalOption:
+			beanTag.Optional = true
+		case LazyOption:
+			beanTag.Lazy = true
+		}
+	}
+	if !beanTag.Auto {
+		beanTag.Name = strings.TrimSpace(items[0])
+	}
+	return beanTag, hasBean || beanTag.Auto || qualifier != ""
+}
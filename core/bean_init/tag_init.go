@@ -3,17 +3,21 @@ package bean_init
 import (
 	"fmt"
 	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/config"
 	"github.com/cutexingluo/go-spring/core/parse"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
-	DefaultValue  = "default" // default value if it is nil.  默认值
-	OverrideValue = "value"   // override value, existing values will also be overwritten.  覆盖值, 就算已存在的值也会被覆盖
-	CapValue      = "cap"     // the slice cap value. 切片容量
-	BeanValue     = "bean"    // the bean value.  bean 值
+	DefaultValue  = "default"  // default value if it is nil.  默认值
+	OverrideValue = "value"    // override value, existing values will also be overwritten.  覆盖值, 就算已存在的值也会被覆盖
+	CapValue      = "cap"      // the slice cap value. 切片容量
+	BeanValue     = "bean"     // the bean value.  bean 值
+	ConfigValue   = config.Tag // bind the properties of the prefix, such as `config:"server"`. 按前缀绑定配置
+	RefreshValue  = "refresh"  // re-initialize the field after the config files are reloaded. 配置变更后重新初始化
 )
 
 // Initialize -初始化目标对象(InitializeByPtr 和 InitializeByStruct 整合版本)，返回新对象，
@@ -37,54 +41,200 @@ func InitializeByStruct[T any](src T) (value T, err error) {
 	return dstVal.Interface().(T), err
 }
 
-// ParseValue  解析某个对象的value
+// InitializeWith - like Initialize, the dependencies of the bean fields are added to the BeanTopo of the factory.
+// 初始化目标对象, bean 字段的依赖添加到指定工厂
+func InitializeWith(factory *core.BeanFactory, srcVal *reflect.Value) (value *reflect.Value, err error) {
+	return parse.InitByTag(srcVal, NewValueParser(factory))
+}
+
+// NewValueParser the tag parser initializing the values, the bean fields are checked in the factory, like ParseValue
+func NewValueParser(factory *core.BeanFactory) *parse.TagParser {
+	return &parse.TagParser{
+		ParseFunc: func(srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) error {
+			return parseValue(factory, srcVal, kind, srcField, structField)
+		},
+	}
+}
+
+// ParseValue  解析某个对象的value, the bean fields are checked in core.Context
 func ParseValue(srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) (err error) {
+	return parseValue(core.Context, srcVal, kind, srcField, structField)
+}
+
+// parseValue  解析某个对象的value
+func parseValue(factory *core.BeanFactory, srcVal *reflect.Value, kind *reflect.Kind, srcField *reflect.Value, structField *reflect.StructField) (err error) {
+	if prefix, ok := structField.Tag.Lookup(ConfigValue); ok { // 按前缀绑定配置
+		if !srcField.CanSet() {
+			return fmt.Errorf("the field '%s' with the tag `%s` must be exported", structField.Name, ConfigValue)
+		}
+		if err = config.BindValue(factory.Environment(), strings.TrimSpace(prefix), srcField); err != nil {
+			return fmt.Errorf("failed to bind the field '%s' of '%s': %w", structField.Name, srcVal.Type(), err)
+		}
+		return nil
+	}
 	if parse.IsSupportBasic(*kind) { // 基本类型
-		tagValue := strings.TrimSpace(structField.Tag.Get(OverrideValue))
+		tagValue, err := resolveTag(factory, structField, OverrideValue)
+		if err != nil {
+			return err
+		}
 		if tagValue == "" {
-			defaultValue := strings.TrimSpace(structField.Tag.Get(DefaultValue)) // 默认值
+			defaultValue, err := resolveTag(factory, structField, DefaultValue) // 默认值
+			if err != nil {
+				return err
+			}
 			if defaultValue != "" && srcField.IsZero() {
-				err = parse.ParseStrSetValue(kind, srcField, defaultValue, 0)
+				return setFieldError(srcVal, structField, DefaultValue, defaultValue, parse.ParseStrSetValue(kind, srcField, defaultValue, 0))
 			}
+			return nil
+		}
+		return setFieldError(srcVal, structField, OverrideValue, tagValue, parse.ParseStrSetValue(kind, srcField, tagValue, 0))
+	} else if beanTag, ok := ParseBeanTag(structField); ok && beanTag.All && parse.IsSupportComposite(*kind) { // bean 集合
+		beanNames, err := collectBeanNames(factory, srcVal, structField, beanTag)
+		if err != nil {
 			return err
 		}
-		err = parse.ParseStrSetValue(kind, srcField, tagValue, 0)
+		for _, beanName := range beanNames {
+			addDependency(factory, srcVal, structField, beanName)
+		}
 	} else if parse.IsSupportComposite(*kind) { // 聚合类型
 		capStr := strings.TrimSpace(structField.Tag.Get(CapValue))
 		var sliceCap = 0
 		if *kind == reflect.Slice && capStr != "" {
-			sliceCap, err = strconv.Atoi(capStr) // if error pass
+			if sliceCap, err = strconv.Atoi(capStr); err != nil { // if error pass
+				sliceCap, err = 0, nil
+			}
+		}
+		tagValue, err := resolveTag(factory, structField, OverrideValue) // 覆盖值
+		if err != nil {
+			return err
 		}
-		tagValue := strings.TrimSpace(structField.Tag.Get(OverrideValue)) // 覆盖值
 		if tagValue == "" {
-			defaultValue := strings.TrimSpace(structField.Tag.Get(DefaultValue)) // 默认值
+			defaultValue, err := resolveTag(factory, structField, DefaultValue) // 默认值
+			if err != nil {
+				return err
+			}
 			if defaultValue != "" && srcField.IsNil() {
-				err = parse.SetValueKV(kind, srcField, defaultValue, sliceCap)
+				return setFieldError(srcVal, structField, DefaultValue, defaultValue, parse.SetValueKV(kind, srcField, defaultValue, sliceCap))
 			}
-			return err
+			return nil
 		}
-		err = parse.SetValueKV(kind, srcField, tagValue, sliceCap)
-	} else if parse.IsSupportBean(*kind) { // 如果是bean类型，则需要解析bean的属性
+		return setFieldError(srcVal, structField, OverrideValue, tagValue, parse.SetValueKV(kind, srcField, tagValue, sliceCap))
+	} else if parse.IsSupportBean(*kind) || *kind == reflect.Interface { // 如果是bean类型，则需要解析bean的属性
 		//fmt.Println(srcVal, kind, srcField, structField, "is bean")
-		beanName := core.BeanNameFilter(structField.Tag.Get(BeanValue)) //获取 bean name
+		beanName, ok, err := beanNameOf(factory, srcVal, structField) //获取 bean name
 		//fmt.Println(beanName)
-		if beanName == "" {
-			return nil
+		if err != nil || !ok {
+			return err
 		}
-		if !core.Context.HasBean(beanName) {
-			return fmt.Errorf(" the bean '%s' is not in the container", beanName)
+		if !factory.HasBean(beanName) {
+			return fmt.Errorf("the bean '%s' of the field '%s' of '%s' is not in the container", beanName, structField.Name, srcVal.Type())
 		}
-		srcType := core.BeanNameFilter(srcVal.Type().String()) // 获取源对象类型
+		addDependency(factory, srcVal, structField, beanName)
+	}
+	return err
+}
 
-		//fmt.Println(srcType, beanName)
-		if core.Context.IsMultiBean(srcType) {
-			names := core.Context.BeanContainer.GetMultiBeanNames(srcType)
-			for _, name := range names { // 该类型的所有bean全部与目标bean进行链接
-				core.Context.BeanTopo.Add(name, beanName) // 添加边
-			}
-		} else if core.Context.IsSingleBean(srcType) {
-			core.Context.BeanTopo.Add(srcType, beanName)
+// RefreshWith re-initialize the fields tagged `refresh:"true"` of the bean pointer in place, after the Environment of
+// the factory is reloaded, such as by config.Watcher. the `config` fields are bound again, the other fields are set by
+// the `value` tag, or the `default` tag even if they are not zero. a field is unchanged if it fails. the bean is locked
+// during the refresh if it implements sync.Locker. 重新初始化 refresh 字段
+func RefreshWith(factory *core.BeanFactory, bean any) (err error) {
+	beanVal := reflect.ValueOf(bean)
+	if beanVal.Kind() != reflect.Ptr || beanVal.IsNil() || beanVal.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	srcVal := beanVal.Elem()
+	var fields []int
+	for i := 0; i < srcVal.NumField(); i++ {
+		if refresh, _ := strconv.ParseBool(srcVal.Type().Field(i).Tag.Get(RefreshValue)); refresh && srcVal.Field(i).CanSet() {
+			fields = append(fields, i)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if locker, ok := bean.(sync.Locker); ok {
+		locker.Lock()
+		defer locker.Unlock()
+	}
+	for _, i := range fields {
+		structField := srcVal.Type().Field(i)
+		if fieldErr := refreshField(factory, srcVal.Field(i), &structField); fieldErr != nil && err == nil {
+			err = fieldErr
 		}
 	}
 	return err
 }
+
+// refreshField initialize a new value of the field by its tags, and set it if no error
+func refreshField(factory *core.BeanFactory, srcField reflect.Value, structField *reflect.StructField) error {
+	value := reflect.New(srcField.Type()).Elem()
+	if prefix, ok := structField.Tag.Lookup(ConfigValue); ok {
+		if err := config.BindValue(factory.Environment(), strings.TrimSpace(prefix), &value); err != nil {
+			return err
+		}
+		srcField.Set(value)
+		return nil
+	}
+	tagValue, err := resolveTag(factory, structField, OverrideValue)
+	if err == nil && tagValue == "" {
+		tagValue, err = resolveTag(factory, structField, DefaultValue)
+	}
+	if err != nil || tagValue == "" {
+		return err
+	}
+	kind := value.Kind()
+	if parse.IsSupportBasic(kind) {
+		err = parse.ParseStrSetValue(&kind, &value, tagValue, 0)
+	} else if parse.IsSupportComposite(kind) {
+		sliceCap, _ := strconv.Atoi(strings.TrimSpace(structField.Tag.Get(CapValue)))
+		err = parse.SetValueKV(&kind, &value, tagValue, sliceCap)
+	} else {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refresh the field '%s' by '%s': %w", structField.Name, tagValue, err)
+	}
+	srcField.Set(value)
+	return nil
+}
+
+// setFieldError wrap the error of setting the field by the tag value, nil if err is nil
+func setFieldError(srcVal *reflect.Value, structField *reflect.StructField, tagName string, tagValue string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to set the field '%s' of '%s' by the tag `%s:\"%s\"`: %w", structField.Name, srcVal.Type(), tagName, tagValue, err)
+}
+
+// resolveTag get the tag value, the placeholders like ${server.port:8080} are resolved by the Environment of the factory
+func resolveTag(factory *core.BeanFactory, structField *reflect.StructField, tagName string) (string, error) {
+	tagValue := strings.TrimSpace(structField.Tag.Get(tagName))
+	if !core.HasPlaceholder(tagValue) || factory.Environment() == nil {
+		return tagValue, nil
+	}
+	resolved, err := factory.Environment().Resolve(tagValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the tag `%s:\"%s\"` of the field '%s': %w", tagName, tagValue, structField.Name, err)
+	}
+	return strings.TrimSpace(resolved), nil
+}
+
+// addDependency add the dependency edge from the bean of srcVal to the bean of beanName, the field is the reason of the edge
+func addDependency(factory *core.BeanFactory, srcVal *reflect.Value, structField *reflect.StructField, beanName string) {
+	if !factory.HasLocalBean(beanName) { // the beans of the parent factory are initialized
+		return
+	}
+	srcType := core.BeanNameFilter(srcVal.Type().String()) // 获取源对象类型
+	reason := fmt.Sprintf("field %s.%s `%s`", srcType, structField.Name, structField.Tag)
+
+	//fmt.Println(srcType, beanName)
+	if factory.BeanContainer.IsMultiBean(srcType) {
+		names := factory.BeanContainer.GetMultiBeanNames(srcType)
+		for _, name := range names { // 该类型的所有bean全部与目标bean进行链接
+			factory.BeanTopo.AddWithReason(name, beanName, reason) // 添加边
+		}
+	} else if factory.BeanContainer.IsSingleBean(srcType) {
+		factory.BeanTopo.AddWithReason(srcType, beanName, reason)
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"github.com/cutexingluo/go-spring/common/base"
+	"sync"
+)
+
+// LazyBinder bind the factory and the bean name to a lazy bean, it is used by the bean injection `bean:"name,lazy"`.
+// 绑定延迟注入的 bean
+type LazyBinder interface {
+	// BindLazy - bind the factory and the bean name, if the beanName is empty, the bean is resolved by type
+	BindLazy(factory *BeanFactory, beanName string)
+}
+
+// Lazy the bean handle resolved on the first Get, the field `Repo core.Lazy[*Repo] bean:"pkg.Repo,lazy"` is injected
+// without a dependency, so it can break the circular dependencies. 延迟注入的 bean, 第一次获取时才解析
+type Lazy[T any] struct {
+	mu       sync.Mutex
+	factory  *BeanFactory
+	beanName string // if it is empty, resolved by the type T
+	resolved bool
+	bean     T
+}
+
+// NewLazy create a lazy bean of the factory, if the beanName is empty, the bean is resolved by the type T
+func NewLazy[T any](factory *BeanFactory, beanName string) *Lazy[T] {
+	lazy := &Lazy[T]{}
+	lazy.BindLazy(factory, beanName)
+	return lazy
+}
+
+// BindLazy bind the factory and the bean name, the resolved bean is reset
+func (_this *Lazy[T]) BindLazy(factory *BeanFactory, beanName string) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	var zero T
+	_this.factory = factory
+	_this.beanName = BeanNameFilter(beanName)
+	_this.resolved = false
+	_this.bean = zero
+}
+
+// Get resolve the bean on the first call, the later calls return the same bean. the error is not cached
+func (_this *Lazy[T]) Get() (T, error) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	if _this.resolved {
+		return _this.bean, nil
+	}
+	var bean T
+	var err error
+	if _this.factory == nil {
+		return bean, &base.ErrIllegalState{ErrMsg: "the lazy bean is not bound to a factory"}
+	} else if _this.beanName == "" {
+		bean, err = Get[T](_this.factory)
+	} else {
+		bean, err = GetNamed[T](_this.factory, _this.beanName)
+	}
+	if err != nil {
+		return bean, err
+	}
+	_this.bean, _this.resolved = bean, true
+	return bean, nil
+}
+
+// MustGet resolve the bean, panic if it failed
+func (_this *Lazy[T]) MustGet() T {
+	bean, err := _this.Get()
+	if err != nil {
+		panic(err)
+	}
+	return bean
+}
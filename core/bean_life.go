@@ -1,71 +1,323 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"github.com/cutexingluo/go-spring/common/base"
 	"reflect"
+	"sort"
+	"sync"
 )
 
-// BeanCreatedFunc BeanCreated
+// BeanCreatedFunc BeanCreated of core.Context
 func BeanCreatedFunc() (err error) {
+	return Context.RunBeanCreated()
+}
+
+// TagInitializedFunc TagInitialized of core.Context
+func TagInitializedFunc() (err error) {
+	return Context.RunTagInitialized()
+}
+
+// BeanInjectedFunc BeanInjected of core.Context
+func BeanInjectedFunc() error {
+	return Context.RunBeanInjected()
+}
+
+// RunBeanCreated BeanCreated, register the Environment, invoke the providers and run the BeanCreated filters
+func (_this *BeanFactory) RunBeanCreated() (err error) {
+	return _this.RunBeanCreatedCtx(context.Background())
+}
+
+// RunBeanCreatedCtx like RunBeanCreated, it stops with ctx.Err() if the ctx is done. 带上下文的 BeanCreated
+func (_this *BeanFactory) RunBeanCreatedCtx(ctx context.Context) (err error) {
 	// BeanCreated
-	allBeanNames := Context.BeanContainer.GetAllBeanNames() // get all bean names
+	if err = _this.registerEnvironment(); err != nil {
+		return err
+	}
+	if err = _this.RegisterConditionalConfigs(); err != nil {
+		return err
+	}
+	if err = _this.invokeProviders(ctx); err != nil {
+		return err
+	}
+	allBeanNames := _this.skipEarlyBeans(_this.BeanContainer.GetAllBeanNames()) // get all bean names
+	return _this.updatePhase(ctx, BeanCreated, allBeanNames)
+}
+
+// RunTagInitialized TagInitialized, run the TagInitialized filters
+func (_this *BeanFactory) RunTagInitialized() (err error) {
+	return _this.RunTagInitializedCtx(context.Background())
+}
+
+// RunTagInitializedCtx like RunTagInitialized, it stops with ctx.Err() if the ctx is done. 带上下文的 TagInitialized
+func (_this *BeanFactory) RunTagInitializedCtx(ctx context.Context) (err error) {
+	// TagInitialized
+	allBeanNames := _this.skipEarlyBeans(_this.BeanContainer.GetAllBeanNames()) // get all bean names
+	return _this.updatePhase(ctx, TagInitialized, allBeanNames)
+}
+
+// updatePhase run the filters of the phase on the beans, the filters of the combined timings run too
+func (_this *BeanFactory) updatePhase(ctx context.Context, phase int, beanNames []string) error {
 	for i := BeanCreated; i < (1 << 3); i++ {
-		if i&BeanCreated != 0 {
-			err = updateFunction(i, allBeanNames)
-			if err != nil {
+		if i&phase != 0 {
+			if err := _this.updateFunction(ctx, i, beanNames); err != nil {
 				return err
 			}
 		}
 	}
-	return
+	return nil
 }
 
-// TagInitializedFunc TagInitialized
-func TagInitializedFunc() (err error) {
-	// TagInitialized
-	allBeanNames := Context.BeanContainer.GetAllBeanNames() // get all bean names
-	for i := TagInitialized; i < (1 << 3); i++ {
-		if i&TagInitialized != 0 {
-			err = updateFunction(i, allBeanNames)
+// RunBeanInjected BeanInjected, run the BeanInjected filters in the order of the dependencies,
+// then the container is frozen, see Freeze
+func (_this *BeanFactory) RunBeanInjected() error {
+	return _this.RunBeanInjectedCtx(context.Background())
+}
+
+// RunBeanInjectedCtx like RunBeanInjected, it stops with ctx.Err() if the ctx is done, and the container is not frozen.
+// 带上下文的 BeanInjected
+func (_this *BeanFactory) RunBeanInjectedCtx(ctx context.Context) error {
+	if err := _this.beanInjected(ctx); err != nil {
+		return err
+	}
+	_this.Freeze()
+	return nil
+}
+
+// beanInjected run the BeanInjected filters and AfterPropertiesSet
+func (_this *BeanFactory) beanInjected(ctx context.Context) error {
+	// BeanInjected
+	if _this.Parallelism > 1 {
+		return _this.beanInjectedParallel(ctx)
+	}
+	build, err := _this.BeanTopo.Build()
+	if err != nil {
+		return err
+	}
+	base.Reverse(build)
+	build = _this.appendIsolatedBeans(build)
+	_this.initOrder = build
+	if err = _this.updatePhase(ctx, BeanInjected, _this.skipEarlyBeans(build)); err != nil {
+		return err
+	}
+	layers := make([][]string, len(build)) // one bean a layer, so the dependents are not called after an error
+	for i, beanName := range build {
+		layers[i] = []string{beanName}
+	}
+	return _this.afterPropertiesSet(ctx, layers, 1)
+}
+
+// afterPropertiesSet call AfterPropertiesSet of the InitializingBean beans layer by layer, the beans of a layer are
+// called concurrently by workers. a bean added by several names is called once. the scoped beans are skipped, they are
+// initialized every time they are created, and the parameter beans of the providers are called by initializeEarly.
+// it stops with ctx.Err() if the ctx is done.
+func (_this *BeanFactory) afterPropertiesSet(ctx context.Context, layers [][]string, workers int) error {
+	called := make(map[beanKey]bool)
+	for beanName := range _this.earlyBeans { // called by initializeEarly
+		if bean, err := _this.GetBean(beanName); err == nil {
+			if key, ok := beanKeyOf(bean); ok {
+				called[key] = true
+			}
+		}
+	}
+	for _, layer := range layers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var names []string
+		var beans []InitializingBean
+		for _, beanName := range layer {
+			if _this.earlyBeans[beanName] || _this.IsScopedBean(beanName) && !_this.BeanContainer.HasBean(beanName) {
+				continue
+			}
+			bean, err := _this.GetBean(beanName)
 			if err != nil {
 				return err
 			}
+			initializingBean, ok := bean.(InitializingBean)
+			if !ok {
+				continue
+			}
+			if key, ok := beanKeyOf(bean); ok {
+				if called[key] {
+					continue
+				}
+				called[key] = true
+			}
+			names = append(names, beanName)
+			beans = append(beans, initializingBean)
+		}
+		if err := RunParallelCtx(ctx, len(beans), workers, func(index int) error {
+			if err := beans[index].AfterPropertiesSet(); err != nil {
+				return fmt.Errorf("failed to initialize the bean '%s': %w", names[index], err)
+			}
+			return nil
+		}); err != nil {
+			return err
 		}
 	}
-	return
+	return nil
 }
 
-// BeanInjectedFunc BeanInjected
-func BeanInjectedFunc() error {
-	// BeanInjected
-	build, err := Context.BeanTopo.Build()
+// appendIsolatedBeans append the beans without any dependency edge (such as the beans with lazy fields only), sorted by name
+func (_this *BeanFactory) appendIsolatedBeans(sorted []string) []string {
+	return append(sorted, _this.isolatedBeans(sorted)...)
+}
+
+// isolatedBeans get the beans in the container but not in the topo, sorted by name
+func (_this *BeanFactory) isolatedBeans(sorted []string) []string {
+	inTopo := make(map[string]bool, len(sorted))
+	for _, beanName := range sorted {
+		inTopo[beanName] = true
+	}
+	var isolated []string
+	for _, beanName := range _this.BeanContainer.GetAllBeanNames() {
+		if !inTopo[beanName] {
+			isolated = append(isolated, beanName)
+		}
+	}
+	sort.Strings(isolated)
+	return isolated
+}
+
+// beanInjectedParallel BeanInjected by the topo layers, the beans of a layer are filtered concurrently by Parallelism
+// workers and updated after the whole layer, the error of the first bean (in layer order) is returned.
+func (_this *BeanFactory) beanInjectedParallel(ctx context.Context) error {
+	layers, err := _this.BeanTopo.BuildLayers()
 	if err != nil {
 		return err
 	}
-	base.Reverse(build)
+	var inTopo []string
+	for _, layer := range layers {
+		inTopo = append(inTopo, layer...)
+	}
+	if isolated := _this.isolatedBeans(inTopo); len(isolated) > 0 { // no dependencies, the first layer
+		if len(layers) == 0 {
+			layers = append(layers, nil)
+		}
+		layers[0] = append(layers[0], isolated...)
+	}
+	_this.initOrder = nil
+	for _, layer := range layers {
+		_this.initOrder = append(_this.initOrder, layer...)
+	}
 	for i := BeanInjected; i < (1 << 3); i++ {
-		if i&BeanInjected != 0 {
-			err := updateFunction(i, build)
-			if err != nil {
-				return err
+		if i&BeanInjected == 0 {
+			continue
+		}
+		for _, funcStruct := range _this.BeanChains[i] {
+			for _, layer := range layers {
+				if err = _this.updateLayerFunction(ctx, funcStruct, _this.skipEarlyBeans(layer)); err != nil {
+					return err
+				}
 			}
 		}
 	}
+	return _this.afterPropertiesSet(ctx, layers, _this.Parallelism)
+}
+
+// updateLayerFunction filter the beans of the layer concurrently, then update them serially
+func (_this *BeanFactory) updateLayerFunction(ctx context.Context, funcStruct *BeanFilterFunction, beanNames []string) error {
+	results := make([]*reflect.Value, len(beanNames))
+	err := RunParallelCtx(ctx, len(beanNames), _this.Parallelism, func(index int) error {
+		beanName := beanNames[index]
+		beanType := _this.BeanContainer.GetType(beanName)
+		// only read here, the writers of the container are serialized, so Update*Filter would serialize the layer
+		var bean *reflect.Value
+		isSingle := _this.BeanContainer.IsSingleBean(beanType) || _this.BeanContainer.IsSingleBean(beanName)
+		if isSingle {
+			bean = _this.BeanContainer.GetSingleBean(beanType)
+		} else if _this.BeanContainer.IsMultiBean(beanName) {
+			bean = _this.BeanContainer.GetMultiBean(beanName)
+		} else {
+			return nil
+		}
+		if isSingle && funcStruct.Mode == SingleBeanMode ||
+			!isSingle && funcStruct.Mode == MultiBeanMode ||
+			funcStruct.Mode == AllBeanMode {
+			var err error
+			results[index], err = funcStruct.Filter(bean)
+			return beanFilterError(beanName, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for index, beanName := range beanNames {
+		if results[index] == nil {
+			continue
+		}
+		_, err = _this.BeanContainer.UpdateBeanFilter(
+			beanName, _this.BeanContainer.GetType(beanName),
+			func(isSingle bool, bean *reflect.Value) (ret *reflect.Value, err error) {
+				return results[index], nil
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunParallel run f(0) ... f(n-1) by at most `workers` goroutines, and wait for all of them.
+// the error of the smallest index is returned, so the error is deterministic. 并行执行, 返回下标最小的错误
+func RunParallel(n int, workers int, f func(index int) error) error {
+	return RunParallelCtx(context.Background(), n, workers, f)
+}
+
+// RunParallelCtx like RunParallel, f is not called for the indexes left after the ctx is done, and ctx.Err() is their
+// error. 带上下文的并行执行
+func RunParallelCtx(ctx context.Context, n int, workers int, f func(index int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, n)
+	semaphore := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if errs[i] = ctx.Err(); errs[i] != nil {
+			break
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			errs[index] = f(index)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// chain of execution functions
-func updateFunction(executionTime int, beanNames []string) error {
-	for _, funcStruct := range Context.BeanChains[executionTime] { // get the function struct
+// chain of execution functions, it stops with ctx.Err() if the ctx is done
+func (_this *BeanFactory) updateFunction(ctx context.Context, executionTime int, beanNames []string) error {
+	for _, funcStruct := range _this.BeanChains[executionTime] { // get the function struct
 		for _, beanName := range beanNames { // iterate all bean names
-			beanType := Context.BeanContainer.GetType(beanName)
-			_, err := Context.BeanContainer.UpdateBeanFilter(
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			beanType := _this.BeanContainer.GetType(beanName)
+			_, err := _this.BeanContainer.UpdateBeanFilter(
 				beanName, beanType,
 				func(isSingle bool, bean *reflect.Value) (ret *reflect.Value, err error) {
 					if isSingle && funcStruct.Mode == SingleBeanMode ||
 						!isSingle && funcStruct.Mode == MultiBeanMode ||
 						funcStruct.Mode == AllBeanMode {
-						return funcStruct.Filter(bean)
+						ret, err = funcStruct.Filter(bean)
+						return ret, beanFilterError(beanName, err)
 					}
 					return nil, nil
 				})
@@ -76,3 +328,11 @@ func updateFunction(executionTime int, beanNames []string) error {
 	}
 	return nil
 }
+
+// beanFilterError wrap the error of the filter with the bean name, nil if err is nil
+func beanFilterError(beanName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to initialize the bean '%s': %w", beanName, err)
+}
@@ -0,0 +1,322 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type parallelLeaf struct {
+	ready bool
+}
+
+func (l *parallelLeaf) AfterPropertiesSet() error {
+	l.ready = true
+	return nil
+}
+
+type parallelMid struct {
+	Leaf  *parallelLeaf `bean:"core_test.parallelLeaf"`
+	ready bool
+}
+
+func (m *parallelMid) AfterPropertiesSet() error {
+	m.ready = m.Leaf != nil && m.Leaf.ready
+	return nil
+}
+
+type parallelTop struct {
+	Mid   *parallelMid  `bean:"core_test.parallelMid"`
+	Leaf  *parallelLeaf `bean:"core_test.parallelLeaf"`
+	ready bool
+}
+
+func (p *parallelTop) AfterPropertiesSet() error {
+	p.ready = p.Mid != nil && p.Mid.ready && p.Leaf != nil && p.Leaf.ready
+	return nil
+}
+
+// parallelWorker the multi beans of a layer, initErr is returned by AfterPropertiesSet, filterErr by the filter
+type parallelWorker struct {
+	Leaf      *parallelLeaf `bean:"core_test.parallelLeaf"`
+	initErr   error
+	filterErr error
+	calls     *atomic.Int32
+}
+
+func (w *parallelWorker) AfterPropertiesSet() error {
+	w.calls.Add(1)
+	return w.initErr
+}
+
+// newParallelFactory the factory with Parallelism 4, the workers of failed is given the errors
+func newParallelFactory(t *testing.T, calls *atomic.Int32, failed int, initErr error, filterErr error) *core.BeanFactory {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	factory.Parallelism = 4
+	frame.InitFactoryBeanFunc(factory)
+	var filtered atomic.Int32
+	frame.AddFactoryBeanFilterFunc(factory, core.BeanInjected, &core.BeanFilterFunction{
+		Mode: core.AllBeanMode,
+		Filter: func(bean *reflect.Value) (*reflect.Value, error) {
+			filtered.Add(1)
+			if worker, ok := bean.Interface().(*parallelWorker); ok {
+				return nil, worker.filterErr
+			}
+			return nil, nil
+		},
+	})
+	multiBeans := make(map[string]any)
+	for i := 0; i < 20; i++ {
+		worker := &parallelWorker{calls: calls}
+		if i == failed {
+			worker.initErr, worker.filterErr = initErr, filterErr
+		}
+		multiBeans[fmt.Sprintf("worker%d", i)] = worker
+	}
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&parallelTop{}, &parallelMid{}, &parallelLeaf{}},
+		MultiBeans:  multiBeans,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunBeanCreated(); err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunTagInitialized(); err != nil {
+		t.Fatal(err)
+	}
+	return factory
+}
+
+// TestParallelBeanInjected the layers are initialized concurrently, it is meant for go test -race
+func TestParallelBeanInjected(t *testing.T) {
+	var calls atomic.Int32
+	factory := newParallelFactory(t, &calls, -1, nil, nil)
+	if err := factory.RunBeanInjected(); err != nil {
+		t.Fatal(err)
+	}
+	if !core.MustGet[*parallelTop](factory).ready {
+		t.Error("the dependencies are not initialized before the bean")
+	}
+	if got := calls.Load(); got != 20 {
+		t.Errorf("AfterPropertiesSet of the workers is called %d times, want 20", got)
+	}
+	if !factory.IsFrozen() {
+		t.Error("the factory is not frozen after BeanInjected")
+	}
+}
+
+// TestParallelBeanInjectedError the error of a worker is returned with the bean name
+func TestParallelBeanInjectedError(t *testing.T) {
+	workerErr := errors.New("worker failed")
+	tests := map[string][2]error{
+		"AfterPropertiesSet": {workerErr, nil},
+		"filter":             {nil, workerErr},
+	}
+	for name, errs := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			factory := newParallelFactory(t, &calls, 7, errs[0], errs[1])
+			err := factory.RunBeanInjected()
+			if !errors.Is(err, workerErr) || !strings.Contains(err.Error(), "'worker7'") {
+				t.Errorf("got the error %v, want the error of worker7", err)
+			}
+		})
+	}
+}
+
+type phaseBean struct{}
+
+// TestPhaseContextCancel the phases stop inside the loop of the beans after the ctx is cancelled by a filter
+func TestPhaseContextCancel(t *testing.T) {
+	type phaseFunc func(factory *core.BeanFactory, ctx context.Context) error
+	created, initialized, injected := (*core.BeanFactory).RunBeanCreatedCtx, (*core.BeanFactory).RunTagInitializedCtx,
+		(*core.BeanFactory).RunBeanInjectedCtx
+	tests := []struct {
+		name        string
+		phase       int
+		parallelism int
+		before      []phaseFunc // run with the background ctx
+		run         phaseFunc
+	}{
+		{"BeanCreated", core.BeanCreated, 1, nil, created},
+		{"TagInitialized", core.TagInitialized, 1, []phaseFunc{created}, initialized},
+		{"BeanInjected", core.BeanInjected, 1, []phaseFunc{created, initialized}, injected},
+		{"parallel BeanInjected", core.BeanInjected, 4, []phaseFunc{created, initialized}, injected},
+	}
+	const n = 20
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			factory := core.NewBeanFactory()
+			factory.Parallelism = test.parallelism
+			var filtered atomic.Int32
+			frame.AddFactoryBeanFilterFunc(factory, test.phase, &core.BeanFilterFunction{
+				Mode: core.AllBeanMode,
+				Filter: func(bean *reflect.Value) (*reflect.Value, error) {
+					filtered.Add(1)
+					cancel()
+					return nil, nil
+				},
+			})
+			for i := 0; i < n; i++ {
+				if _, err := factory.AddMultiBean(fmt.Sprintf("bean%d", i), &phaseBean{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, phase := range test.before {
+				if err := phase(factory, context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := test.run(factory, ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("got the error %v, want context.Canceled", err)
+			}
+			if got := filtered.Load(); got == 0 || got > int32(test.parallelism) {
+				t.Errorf("the filter is called %d times after the ctx is cancelled, want at most %d", got, test.parallelism)
+			}
+			if test.phase == core.BeanInjected && factory.IsFrozen() {
+				t.Error("the factory is frozen after the cancelled BeanInjected")
+			}
+		})
+	}
+}
+
+// TestBeanLifeCycleExecuteCtx the life cycles left are not executed after the ctx is done
+func TestBeanLifeCycleExecuteCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var executed []int
+	err := core.BeanLifeCycleExecuteCtx(ctx, []any{1, nil, 2, 3}, func(ctx context.Context, lifeCycle any) error {
+		executed = append(executed, lifeCycle.(int))
+		if lifeCycle == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || !reflect.DeepEqual(executed, []int{1, 2}) {
+		t.Errorf("got the error %v and executed %v, want context.Canceled after 1 and 2", err, executed)
+	}
+}
+
+// TestRunParallelCtx the indexes left get ctx.Err(), the error of the smallest index is returned
+func TestRunParallelCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var called atomic.Int32
+	err := core.RunParallelCtx(ctx, 10, 1, func(index int) error {
+		called.Add(1)
+		if index == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called.Load() != 3 {
+		t.Errorf("got the error %v and %d calls, want context.Canceled after 3 calls", err, called.Load())
+	}
+
+	err = core.RunParallel(5, 3, func(index int) error {
+		if index >= 1 {
+			return fmt.Errorf("index %d", index)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "index 1" {
+		t.Errorf("got the error %v, want the error of the smallest index", err)
+	}
+}
+
+// hookRecorder record the calls of AfterPropertiesSet
+type hookRecorder struct {
+	name  string
+	order *[]string
+	err   error
+}
+
+func (r *hookRecorder) AfterPropertiesSet() error {
+	*r.order = append(*r.order, r.name)
+	return r.err
+}
+
+type hookDB struct {
+	hookRecorder
+}
+
+type hookRepo struct {
+	hookRecorder
+	DB *hookDB `bean:"core_test.hookDB"`
+}
+
+// hookService it checks its dependency is initialized in AfterPropertiesSet
+type hookService struct {
+	Repo  *hookRepo `bean:"core_test.hookRepo"`
+	ready bool
+}
+
+func (s *hookService) AfterPropertiesSet() error {
+	s.ready = s.Repo != nil && len(*s.Repo.order) == 2
+	return nil
+}
+
+// TestAfterPropertiesSet the beans are initialized by themselves after the injection, dependencies first and once each
+func TestAfterPropertiesSet(t *testing.T) {
+	var order []string
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	shared := &hookRecorder{name: "shared", order: &order}
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{
+			&hookService{},
+			&hookRepo{hookRecorder: hookRecorder{name: "repo", order: &order}},
+			&hookDB{hookRecorder: hookRecorder{name: "db", order: &order}},
+		},
+		MultiBeans: map[string]any{"first": shared, "second": shared},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	if !core.MustGet[*hookService](factory).ready {
+		t.Error("the dependencies are not initialized before the bean")
+	}
+	if len(order) != 3 || order[0] == "repo" || order[len(order)-1] == "db" {
+		t.Errorf("got the order %v, want db before repo and the shared bean once", order)
+	}
+}
+
+// TestAfterPropertiesSetError the error stops BeanInjected with the bean name, the factory is not frozen
+func TestAfterPropertiesSetError(t *testing.T) {
+	var order []string
+	initErr := errors.New("init failed")
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{SingleBeans: []any{
+		&hookRepo{hookRecorder: hookRecorder{name: "repo", order: &order}},
+		&hookDB{hookRecorder: hookRecorder{name: "db", order: &order, err: initErr}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunBeanCreated(); err != nil {
+		t.Fatal(err)
+	}
+	if err = factory.RunTagInitialized(); err != nil {
+		t.Fatal(err)
+	}
+	err = factory.RunBeanInjected()
+	if !errors.Is(err, initErr) || !strings.Contains(err.Error(), "'core_test.hookDB'") {
+		t.Errorf("got the error %v, want the error of core_test.hookDB", err)
+	}
+	if !reflect.DeepEqual(order, []string{"db"}) || factory.IsFrozen() {
+		t.Errorf("got the order %v and frozen %v, want only db called and not frozen", order, factory.IsFrozen())
+	}
+}
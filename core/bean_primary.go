@@ -0,0 +1,56 @@
+package core
+
+import (
+	"github.com/cutexingluo/go-spring/common/reflect_util"
+	"github.com/cutexingluo/go-spring/common/se/slice_util"
+	"reflect"
+)
+
+// SetPrimary mark the bean as the primary bean of its type, it is chosen when several beans match the type.
+// 设置主 bean, 同类型存在多个 bean 时优先选择
+func (_this *BeanFactory) SetPrimary(beanName string) error {
+	beanName = _this.CanonicalName(beanName)
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "SetPrimary", BeanName: beanName}
+	}
+	if !_this.HasBean(beanName) {
+		return &ErrBeanNotFound{BeanName: beanName}
+	}
+	_this.primaries[beanName] = true
+	return nil
+}
+
+// IsPrimary checks whether the bean is primary, by SetPrimary or core.PrimaryBean. 是否为主 bean
+func (_this *BeanFactory) IsPrimary(beanName string) bool {
+	beanName = _this.CanonicalName(beanName)
+	if _this.primaries[beanName] {
+		return true
+	}
+	bean, err := _this.BeanContainer.GetBean(beanName)
+	if err != nil || bean == nil {
+		return !_this.HasLocalBean(beanName) && _this.Parent != nil && _this.Parent.IsPrimary(beanName)
+	}
+	primaryBean, ok := reflect_util.GetPtrByValue(bean).Interface().(PrimaryBean)
+	return ok && primaryBean.IsPrimary()
+}
+
+// ResolveQualifiedBeanName get the bean name by the bean type and the qualifier (bean name),
+// the qualifier must be one of the beans matching the type. 根据类型和限定名获取 bean 名称
+func (_this *BeanFactory) ResolveQualifiedBeanName(beanType reflect.Type, qualifier string) (string, error) {
+	qualifier = _this.CanonicalName(qualifier)
+	if qualifier == "" {
+		return _this.ResolveBeanName(beanType)
+	}
+	names := _this.GetBeanNamesByType(beanType)
+	if slice_util.ContainsString(names, qualifier) {
+		return qualifier, nil
+	}
+	if !_this.HasBean(qualifier) {
+		return "", &ErrBeanNotFound{BeanName: qualifier}
+	}
+	actualType := qualifier
+	if bean, err := _this.GetBeanValue(qualifier); err == nil && bean != nil {
+		actualType = bean.Type().String()
+	}
+	return "", &ErrBeanTypeMismatch{BeanName: qualifier, RequiredType: beanType.String(), ActualType: actualType}
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/base"
+	"github.com/cutexingluo/go-spring/common/reflect_util"
+	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"runtime"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	factoryType = reflect.TypeOf((*BeanFactory)(nil))
+)
+
+// beanProvider the constructor of a single bean. 构造函数
+type beanProvider struct {
+	name     string        // function name
+	fn       reflect.Value // func(deps...) bean  or  func(deps...) (bean, error)
+	beanType string        // the type of the returned bean
+}
+
+// newBeanProvider check the provider function
+func newBeanProvider(provider any) (*beanProvider, error) {
+	fn := reflect.ValueOf(provider)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("the provider '%T' is not a function", provider)
+	}
+	name := runtime.FuncForPC(fn.Pointer()).Name()
+	fnType := fn.Type()
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 || fnType.NumOut() == 2 && fnType.Out(1) != errorType {
+		return nil, fmt.Errorf("the provider '%s' must return (bean) or (bean, error)", name)
+	}
+	if !parse.IsSupportBean(fnType.Out(0).Kind()) {
+		return nil, fmt.Errorf("the provider '%s' must return a ptr or struct bean, not '%s'", name, fnType.Out(0))
+	}
+	for i := 0; i < fnType.NumIn(); i++ {
+		if !parse.IsSupportBean(fnType.In(i).Kind()) && fnType.In(i).Kind() != reflect.Interface {
+			return nil, fmt.Errorf("the parameter %d of the provider '%s' must be a ptr, struct or interface bean, not '%s'", i, name, fnType.In(i))
+		}
+	}
+	return &beanProvider{
+		name:     name,
+		fn:       fn,
+		beanType: BeanNameFilter(fnType.Out(0).String()),
+	}, nil
+}
+
+// provides checks whether the returned bean can be used as the parameter of paramType
+func (_this *beanProvider) provides(paramType reflect.Type) bool {
+	outType := _this.fn.Type().Out(0)
+	if paramType.Kind() == reflect.Interface {
+		if outType.Kind() != reflect.Ptr {
+			outType = reflect.PtrTo(outType)
+		}
+		return outType.Implements(paramType)
+	}
+	return BeanNameFilter(paramType.String()) == _this.beanType
+}
+
+// Provide add a constructor of a single bean, such as func(db *DB, cfg *Config) (*Repo, error).
+// the parameters are resolved from the container by type, and the returned bean is added as a single bean.
+// the providers are invoked in the BeanCreated phase, in the order of their dependencies. the parameter beans are fully
+// initialized before the provider is called: the BeanCreated, TagInitialized and BeanInjected filters and
+// AfterPropertiesSet run on each of them after its dependencies, and the phases skip them later. so the bean fields of a
+// parameter bean can not be provided by the providers invoked after it, and a circular dependency of them returns
+// parse.ErrCircularDependency.
+// 添加构造函数, 参数会根据类型从容器中获取并提前完成初始化, 返回值作为单例 bean 添加到容器
+func (_this *BeanFactory) Provide(provider any) error {
+	p, err := newBeanProvider(provider)
+	if err != nil {
+		return err
+	}
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "Provide", BeanName: p.beanType}
+	}
+	_this.providers = append(_this.providers, p)
+	return nil
+}
+
+// InvokeProviders invoke all added providers in the order of their dependencies, it is called by BeanCreatedFunc.
+// 按依赖顺序执行所有构造函数
+func (_this *BeanFactory) InvokeProviders() error {
+	return _this.invokeProviders(context.Background())
+}
+
+// invokeProviders invoke the providers, it stops with ctx.Err() if the ctx is done
+func (_this *BeanFactory) invokeProviders(ctx context.Context) error {
+	providers := _this.providers
+	if len(providers) == 0 {
+		return nil
+	}
+	_this.providers = nil
+
+	byType := make(map[string]*beanProvider, len(providers))
+	for _, p := range providers {
+		if other, ok := byType[p.beanType]; ok {
+			return fmt.Errorf("the bean '%s' is provided by both '%s' and '%s'", p.beanType, other.name, p.name)
+		}
+		if _this.BeanContainer.HasBeanType(p.beanType) {
+			return fmt.Errorf("the bean '%s' of the provider '%s' is already in the container", p.beanType, p.name)
+		}
+		byType[p.beanType] = p
+	}
+
+	// the providers depend on each other are sorted by the topo, the others are invoked first
+	topo := parse.NewBeanTopo()
+	linked := make(map[string]bool)
+	for _, p := range providers {
+		fnType := p.fn.Type()
+		for i := 0; i < fnType.NumIn(); i++ {
+			for _, dependency := range providers {
+				if dependency.provides(fnType.In(i)) {
+					topo.AddWithReason(p.beanType, dependency.beanType, fmt.Sprintf("parameter %d of the provider '%s'", i, p.name))
+					linked[p.beanType] = true
+					linked[dependency.beanType] = true
+				}
+			}
+		}
+	}
+	sorted, err := topo.Build()
+	if err != nil {
+		return err
+	}
+	base.Reverse(sorted)
+
+	ordered := make([]*beanProvider, 0, len(providers))
+	for _, p := range providers {
+		if !linked[p.beanType] {
+			ordered = append(ordered, p)
+		}
+	}
+	for _, beanType := range sorted {
+		ordered = append(ordered, byType[beanType])
+	}
+	for _, p := range ordered {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = _this.invokeProvider(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invokeProvider resolve the parameters, invoke the provider and add the bean
+func (_this *BeanFactory) invokeProvider(ctx context.Context, p *beanProvider) error {
+	fnType := p.fn.Type()
+	args := make([]reflect.Value, fnType.NumIn())
+	for i := range args {
+		paramType := fnType.In(i)
+		if paramType == factoryType {
+			args[i] = reflect.ValueOf(_this)
+			continue
+		}
+		beanName, err := _this.ResolveBeanName(paramType)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the parameter %d of the provider '%s': %w", i, p.name, err)
+		}
+		if err = _this.initializeEarly(ctx, beanName, nil); err != nil {
+			return fmt.Errorf("failed to initialize the parameter %d of the provider '%s': %w", i, p.name, err)
+		}
+		bean, err := _this.GetBeanValue(beanName)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the parameter %d of the provider '%s': %w", i, p.name, err)
+		}
+		if paramType.Kind() == reflect.Ptr || paramType.Kind() == reflect.Interface {
+			args[i] = *reflect_util.GetPtrByValue(bean)
+		} else { // copy 一份
+			args[i] = *reflect_util.GetStructByValue(bean)
+		}
+	}
+	results := p.fn.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return fmt.Errorf("the provider '%s' failed: %w", p.name, results[1].Interface().(error))
+	}
+	if results[0].Kind() == reflect.Ptr && results[0].IsNil() {
+		return fmt.Errorf("the provider '%s' returns a nil bean", p.name)
+	}
+	_, err := _this.AddSingleBean(results[0].Interface())
+	return err
+}
+
+// initializeEarly run the filters of all phases and AfterPropertiesSet on the bean of the container before the phases,
+// the dependencies found by TagInitialized are initialized before BeanInjected. visiting is the path of the beans being
+// initialized, a bean on it again is a circular dependency
+func (_this *BeanFactory) initializeEarly(ctx context.Context, beanName string, visiting []string) error {
+	if _this.earlyBeans[beanName] || !_this.BeanContainer.HasBean(beanName) {
+		return nil
+	}
+	for index, visited := range visiting {
+		if visited == beanName {
+			return _this.BeanTopo.CycleError(visiting[index:])
+		}
+	}
+	visiting = append(visiting, beanName)
+	for _, phase := range []int{BeanCreated, TagInitialized} {
+		if err := _this.updatePhase(ctx, phase, []string{beanName}); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range _this.BeanTopo.GetDependencies(beanName) {
+		if err := _this.initializeEarly(ctx, dependency, visiting); err != nil {
+			return err
+		}
+	}
+	if err := _this.updatePhase(ctx, BeanInjected, []string{beanName}); err != nil {
+		return err
+	}
+	bean, err := _this.GetBean(beanName)
+	if err != nil {
+		return err
+	}
+	if initializingBean, ok := bean.(InitializingBean); ok {
+		if err = initializingBean.AfterPropertiesSet(); err != nil {
+			return fmt.Errorf("failed to initialize the bean '%s': %w", beanName, err)
+		}
+	}
+	_this.earlyBeans[beanName] = true
+	return nil
+}
+
+// skipEarlyBeans get the bean names without the beans initialized early
+func (_this *BeanFactory) skipEarlyBeans(beanNames []string) []string {
+	if len(_this.earlyBeans) == 0 {
+		return beanNames
+	}
+	names := make([]string, 0, len(beanNames))
+	for _, beanName := range beanNames {
+		if !_this.earlyBeans[beanName] {
+			names = append(names, beanName)
+		}
+	}
+	return names
+}
@@ -0,0 +1,147 @@
+package core_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"testing"
+)
+
+type providerConfig struct {
+	URL  string      `value:"${db.url:def}"`
+	Size int         `default:"8"`
+	DB   *providerDB `bean:"core_test.providerDB"`
+}
+
+type providerDB struct {
+	Name string `value:"${db.name}"`
+}
+
+type providerRepo struct {
+	URL    string
+	Size   int
+	DBName string
+}
+
+// runPhases run the phases of the factory like application.Run
+func runPhases(t *testing.T, factory *core.BeanFactory) {
+	t.Helper()
+	if err := factory.RunBeanCreated(); err != nil {
+		t.Fatalf("BeanCreated: %v", err)
+	}
+	if err := factory.RunTagInitialized(); err != nil {
+		t.Fatalf("TagInitialized: %v", err)
+	}
+	if err := factory.RunBeanInjected(); err != nil {
+		t.Fatalf("BeanInjected: %v", err)
+	}
+}
+
+func TestProvideParameterInitialized(t *testing.T) {
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	factory.Environment().AddFirst(core.NewMapPropertySource("test", map[string]string{"db.name": "main"}))
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&providerConfig{}, &providerDB{}},
+		Providers: []any{func(cfg *providerConfig) *providerRepo {
+			repo := &providerRepo{URL: cfg.URL, Size: cfg.Size}
+			if cfg.DB != nil {
+				repo.DBName = cfg.DB.Name
+			}
+			return repo
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+
+	repo, err := core.Get[*providerRepo](factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.URL != "def" {
+		t.Errorf("the provider got URL %q, want %q", repo.URL, "def")
+	}
+	if repo.Size != 8 {
+		t.Errorf("the provider got Size %d, want 8", repo.Size)
+	}
+	if repo.DBName != "main" {
+		t.Errorf("the provider got the injected DB name %q, want %q", repo.DBName, "main")
+	}
+	cfg := core.MustGet[*providerConfig](factory)
+	if cfg.URL != "def" || cfg.DB == nil {
+		t.Errorf("the parameter bean is changed by the phases: %+v", cfg)
+	}
+}
+
+type providerInit struct {
+	Name  string `value:"${init.name:x}"`
+	ready bool
+	calls int
+}
+
+func (p *providerInit) AfterPropertiesSet() error {
+	p.ready = p.Name == "x"
+	p.calls++
+	return nil
+}
+
+type providerService struct {
+	ready bool
+}
+
+// TestProvideParameterAfterPropertiesSet the provider gets the parameter bean after its AfterPropertiesSet, which is
+// called once
+func TestProvideParameterAfterPropertiesSet(t *testing.T) {
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&providerInit{}},
+		Providers: []any{func(init *providerInit) *providerService {
+			return &providerService{ready: init.ready}
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	if !core.MustGet[*providerService](factory).ready {
+		t.Error("the provider got the parameter bean before AfterPropertiesSet")
+	}
+	if calls := core.MustGet[*providerInit](factory).calls; calls != 1 {
+		t.Errorf("AfterPropertiesSet is called %d times, want once", calls)
+	}
+}
+
+type providerCycleA struct {
+	B *providerCycleB `bean:"core_test.providerCycleB"`
+}
+
+type providerCycleB struct {
+	A *providerCycleA `bean:"core_test.providerCycleA"`
+}
+
+// TestProvideParameterCycle a circular dependency through a parameter bean is an error
+func TestProvideParameterCycle(t *testing.T) {
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&providerCycleA{}, &providerCycleB{}},
+		Providers:   []any{func(a *providerCycleA) *providerRepo { return &providerRepo{} }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = factory.RunBeanCreated()
+	var cycleErr *parse.ErrCircularDependency
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("got the error %v, want ErrCircularDependency", err)
+	}
+	want := []string{"core_test.providerCycleA", "core_test.providerCycleB", "core_test.providerCycleA"}
+	if len(cycleErr.Cycles) != 1 || !reflect.DeepEqual(cycleErr.Cycles[0].Beans, want) {
+		t.Errorf("got the cycles %+v, want %v", cycleErr.Cycles, want)
+	}
+}
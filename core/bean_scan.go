@@ -1,5 +1,7 @@
 package core
 
+import "context"
+
 // BeanLifeCycleExecute execute the beanLifeCycle
 func BeanLifeCycleExecute(lifeCycles []interface{}, f func(lifeCycle any) error) (err error) {
 	if len(lifeCycles) == 0 {
@@ -17,11 +19,90 @@ func BeanLifeCycleExecute(lifeCycles []interface{}, f func(lifeCycle any) error)
 	return
 }
 
-// CreateHandlerExecute execute the createHandler
+// BeanLifeCycleExecuteParallel execute the beanLifeCycle concurrently by at most `workers` goroutines,
+// the error of the first lifeCycle is returned. 并行执行
+func BeanLifeCycleExecuteParallel(lifeCycles []interface{}, workers int, f func(lifeCycle any) error) (err error) {
+	return RunParallel(len(lifeCycles), workers, func(index int) error {
+		if lifeCycles[index] == nil {
+			return nil
+		}
+		return f(lifeCycles[index])
+	})
+}
+
+// BeanLifeCycleExecuteParallelCtx like BeanLifeCycleExecuteParallel with the context, the lifeCycles left are not
+// executed after the ctx is done, and ctx.Err() is returned. 带上下文的并行执行
+func BeanLifeCycleExecuteParallelCtx(ctx context.Context, lifeCycles []interface{}, workers int, f func(ctx context.Context, lifeCycle any) error) (err error) {
+	return RunParallelCtx(ctx, len(lifeCycles), workers, func(index int) error {
+		if lifeCycles[index] == nil {
+			return nil
+		}
+		return f(ctx, lifeCycles[index])
+	})
+}
+
+// BeanLifeCycleExecuteCtx execute the beanLifeCycle with the context, it stops with ctx.Err() if the ctx is done
+func BeanLifeCycleExecuteCtx(ctx context.Context, lifeCycles []interface{}, f func(ctx context.Context, lifeCycle any) error) (err error) {
+	for _, lifeCycle := range lifeCycles {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if lifeCycle == nil {
+			continue
+		}
+		err = f(ctx, lifeCycle)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// CreateHandlerExecute execute the createHandler of core.Context
 func CreateHandlerExecute(lifeCycle interface{}) (err error) {
+	return Context.CreateHandlerExecute(lifeCycle)
+}
+
+// AfterInstantiationHandlerExecute execute the afterInstantiationHandler of core.Context
+func AfterInstantiationHandlerExecute(lifeCycle interface{}) (err error) {
+	return Context.AfterInstantiationHandlerExecute(lifeCycle)
+}
+
+// AfterInitializationHandlerExecute execute the afterInitializationHandler of core.Context
+func AfterInitializationHandlerExecute(lifeCycle interface{}) (err error) {
+	return Context.AfterInitializationHandlerExecute(lifeCycle)
+}
+
+// AfterInstantiationHandlerExecuteCtx execute the afterInstantiationCtxHandler or the afterInstantiationHandler of core.Context
+func AfterInstantiationHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	return Context.AfterInstantiationHandlerExecuteCtx(ctx, lifeCycle)
+}
+
+// AfterInitializationHandlerExecuteCtx execute the afterInitializationCtxHandler or the afterInitializationHandler of core.Context
+func AfterInitializationHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	return Context.AfterInitializationHandlerExecuteCtx(ctx, lifeCycle)
+}
+
+// ChooseMainHandlerExecuteCtx choose the main handler of core.Context, the context-aware handlers come first
+func ChooseMainHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	return Context.ChooseMainHandlerExecuteCtx(ctx, lifeCycle)
+}
+
+// ChooseMainHandlerExecute choose the main handler of core.Context
+func ChooseMainHandlerExecute(lifeCycle interface{}) (err error) {
+	return Context.ChooseMainHandlerExecute(lifeCycle)
+}
+
+// AddBeanConfig adds beans to core.Context
+func AddBeanConfig(beanConfig *BeanConfig) (err error) {
+	return Context.AddBeanConfig(beanConfig)
+}
+
+// CreateHandlerExecute execute the createHandler
+func (_this *BeanFactory) CreateHandlerExecute(lifeCycle interface{}) (err error) {
 	if createHandler, ok := lifeCycle.(CreateHandler); ok {
 		beanConfig := createHandler.Create()
-		err = AddBeanConfig(beanConfig)
+		err = _this.AddBeanConfig(beanConfig)
 		if err != nil {
 			return
 		}
@@ -30,9 +111,9 @@ func CreateHandlerExecute(lifeCycle interface{}) (err error) {
 }
 
 // AfterInstantiationHandlerExecute execute the afterInstantiationHandler
-func AfterInstantiationHandlerExecute(lifeCycle interface{}) (err error) {
+func (_this *BeanFactory) AfterInstantiationHandlerExecute(lifeCycle interface{}) (err error) {
 	if afterInstantiationHandler, ok := lifeCycle.(AfterInstantiationHandler); ok {
-		err = afterInstantiationHandler.AfterInstantiation(Context)
+		err = afterInstantiationHandler.AfterInstantiation(_this)
 		if err != nil {
 			return
 		}
@@ -41,9 +122,9 @@ func AfterInstantiationHandlerExecute(lifeCycle interface{}) (err error) {
 }
 
 // AfterInitializationHandlerExecute execute the afterInitializationHandler
-func AfterInitializationHandlerExecute(lifeCycle interface{}) (err error) {
+func (_this *BeanFactory) AfterInitializationHandlerExecute(lifeCycle interface{}) (err error) {
 	if afterInitializationHandler, ok := lifeCycle.(AfterInitializationHandler); ok {
-		err = afterInitializationHandler.AfterInitialization(Context)
+		err = afterInitializationHandler.AfterInitialization(_this)
 		if err != nil {
 			return
 		}
@@ -51,53 +132,140 @@ func AfterInitializationHandlerExecute(lifeCycle interface{}) (err error) {
 	return
 }
 
+// AfterInstantiationHandlerExecuteCtx execute the afterInstantiationCtxHandler, or the afterInstantiationHandler
+func (_this *BeanFactory) AfterInstantiationHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	if handler, ok := lifeCycle.(AfterInstantiationCtxHandler); ok {
+		return handler.AfterInstantiationCtx(ctx, _this)
+	}
+	return _this.AfterInstantiationHandlerExecute(lifeCycle)
+}
+
+// AfterInitializationHandlerExecuteCtx execute the afterInitializationCtxHandler, or the afterInitializationHandler
+func (_this *BeanFactory) AfterInitializationHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	if handler, ok := lifeCycle.(AfterInitializationCtxHandler); ok {
+		return handler.AfterInitializationCtx(ctx, _this)
+	}
+	return _this.AfterInitializationHandlerExecute(lifeCycle)
+}
+
+// ChooseMainHandlerExecuteCtx choose the main handler, the context-aware handlers come first
+func (_this *BeanFactory) ChooseMainHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	if onMainCtxHandler, ok := lifeCycle.(OnMainCtxHandler); ok {
+		useGo, destroy, err := onMainCtxHandler.OnMainCtx(ctx, _this)
+		if err != nil {
+			return err
+		} else if useGo != nil {
+			_this.Go(func() {
+				useGo(ctx)
+			})
+		}
+		if destroy {
+			return _this.destroyHandlerExecuteCtx(ctx, lifeCycle)
+		}
+		return nil
+	} else if _, ok2 := lifeCycle.(OnMainHandler); ok2 {
+		return _this.ChooseMainHandlerExecute(lifeCycle)
+	} else if mainCtxHandler, ok3 := lifeCycle.(MainCtxHandler); ok3 {
+		return mainCtxHandler.OnMainCtx(ctx, _this)
+	}
+	return _this.ChooseMainHandlerExecute(lifeCycle)
+}
+
+// destroyHandlerExecuteCtx execute the destroyCtxHandler, or the destroyHandler
+func (_this *BeanFactory) destroyHandlerExecuteCtx(ctx context.Context, lifeCycle interface{}) (err error) {
+	if destroyCtxHandler, ok := lifeCycle.(DestroyCtxHandler); ok {
+		return destroyCtxHandler.DestroyCtx(ctx, _this)
+	} else if destroyHandler, ok2 := lifeCycle.(DestroyHandler); ok2 {
+		return destroyHandler.Destroy(_this)
+	}
+	return
+}
+
 // ChooseMainHandlerExecute choose the main handler
-func ChooseMainHandlerExecute(lifeCycle interface{}) (err error) {
+func (_this *BeanFactory) ChooseMainHandlerExecute(lifeCycle interface{}) (err error) {
 	if onMainHandler, ok := lifeCycle.(OnMainHandler); ok {
-		return onMainAndDestroyHandlerExecute(onMainHandler)
+		return _this.onMainAndDestroyHandlerExecute(onMainHandler)
 	} else if mainHandler, ok2 := lifeCycle.(MainHandler); ok2 {
-		return mainHandlerExecute(mainHandler)
+		return _this.mainHandlerExecute(mainHandler)
 	}
 	return
 }
 
 // MainHandlerExecute execute the mainHandler
-func mainHandlerExecute(mainHandler MainHandler) (err error) {
-	return mainHandler.OnMain(Context)
+func (_this *BeanFactory) mainHandlerExecute(mainHandler MainHandler) (err error) {
+	return mainHandler.OnMain(_this)
 }
 
 // OnMainAndDestroyHandlerExecute execute the OnMainHandler and DestroyHandler
-func onMainAndDestroyHandlerExecute(onMainHandler OnMainHandler) (err error) {
-	useGo, destroy, err := onMainHandler.OnMain(Context)
+func (_this *BeanFactory) onMainAndDestroyHandlerExecute(onMainHandler OnMainHandler) (err error) {
+	useGo, destroy, err := onMainHandler.OnMain(_this)
 	if err != nil {
 		return err
 	} else if useGo != nil {
-		go useGo()
+		_this.Go(useGo)
 	}
 	if destroy {
-		if destroyHandler, ok := onMainHandler.(DestroyHandler); ok {
-			err = destroyHandler.Destroy(Context)
-			if err != nil {
-				return
-			}
-		}
+		return _this.destroyHandlerExecuteCtx(context.Background(), onMainHandler)
 	}
 	return
 }
 
-// AddBeanConfig adds beans
-func AddBeanConfig(beanConfig *BeanConfig) (err error) {
+// AddBeanConfig adds beans, the config with profiles, conditions or conditional beans is registered before BeanCreated,
+// see RegisterConditionalConfigs
+func (_this *BeanFactory) AddBeanConfig(beanConfig *BeanConfig) (err error) {
 	if beanConfig == nil {
 		return
 	}
+	if beanConfig.isConditional() {
+		if _this.IsFrozen() {
+			return &ErrContainerFrozen{Operation: "AddBeanConfig", BeanName: "<conditional>"}
+		}
+		_this.conditionalConfigs = append(_this.conditionalConfigs, beanConfig)
+		return
+	}
+	return _this.addBeanConfig(beanConfig)
+}
+
+// addBeanConfig adds the beans of the config, the conditional beans are added if they match
+func (_this *BeanFactory) addBeanConfig(beanConfig *BeanConfig) (err error) {
 	for beanName, bean := range beanConfig.MultiBeans {
-		_, err = Context.AddMultiBean(beanName, bean)
+		if bean, ok := _this.unwrapConditional(bean); ok {
+			_, err = _this.AddMultiBean(beanName, bean)
+			if err != nil {
+				return
+			}
+		}
+	}
+	for _, bean := range beanConfig.SingleBeans {
+		if bean, ok := _this.unwrapConditional(bean); ok {
+			_, err = _this.AddSingleBean(bean)
+			if err != nil {
+				return
+			}
+		}
+	}
+	for beanName, scopedBean := range beanConfig.ScopedBeans {
+		_, err = _this.AddScopedBean(beanName, scopedBean)
 		if err != nil {
 			return
 		}
 	}
-	for _, bean := range beanConfig.SingleBeans {
-		_, err = Context.AddSingleBean(bean)
+	for _, provider := range beanConfig.Providers {
+		if provider, ok := _this.unwrapConditional(provider); ok {
+			err = _this.Provide(provider)
+			if err != nil {
+				return
+			}
+		}
+	}
+	for alias, beanName := range beanConfig.Aliases {
+		err = _this.RegisterAlias(alias, beanName)
+		if err != nil {
+			return
+		}
+	}
+	for _, beanName := range beanConfig.Primary {
+		err = _this.SetPrimary(beanName)
 		if err != nil {
 			return
 		}
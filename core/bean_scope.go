@@ -0,0 +1,219 @@
+package core
+
+import (
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/reflect_util"
+	"reflect"
+	"sort"
+)
+
+// bean scopes, singleton is the default scope of the single beans and multi beans in the container
+const (
+	ScopeSingleton = "singleton" // created once in BeanCreated, 单例 (包含 SingleBean 和 MultiBean)
+	ScopePrototype = "prototype" // created on every GetBean, 每次获取都创建新的实例
+)
+
+// Scope the bean scope, you can implement it to add a custom scope, such as per-goroutine or per-request scope.
+// 作用域接口, 可以实现该接口添加自定义作用域
+type Scope interface {
+	// Get return the bean of the scope, if it does not exist, create it by objectFactory. 获取作用域中的 bean, 不存在则创建
+	Get(beanName string, objectFactory func() (*reflect.Value, error)) (*reflect.Value, error)
+	// Remove remove the bean from the scope, return the removed bean, if nil do nothing return nil. 移除作用域中的 bean
+	Remove(beanName string) *reflect.Value
+	// RegisterDestructionCallback register the callback executed when the bean is removed from the scope. 注册销毁回调
+	RegisterDestructionCallback(beanName string, callback func())
+}
+
+// ScopedBean the definition of a scoped bean. 作用域 bean 的定义
+//
+// Factory only builds the raw instance, the BeanFactory passes it through the BeanChains
+// (BeanCreated, TagInitialized, BeanInjected) like a multi bean every time it is created.
+type ScopedBean struct {
+	Scope   string     // scope name, such as core.ScopePrototype  作用域名称
+	Factory func() any // create a new raw instance, if it is not a ptr type, it will be converted to a ptr type  创建新的实例
+	// Type the ptr type of the bean, used by the lookups by type. if it is nil, it is got from a raw instance created by
+	// Factory on the first lookup by type. bean 的指针类型, 用于按类型查找
+	Type reflect.Type
+}
+
+// PrototypeScope the prototype scope, a new bean is created on every Get. 原型作用域, 每次获取都创建新的实例
+type PrototypeScope struct {
+}
+
+// Get create a new bean by objectFactory
+func (_this *PrototypeScope) Get(beanName string, objectFactory func() (*reflect.Value, error)) (*reflect.Value, error) {
+	return objectFactory()
+}
+
+// Remove prototype beans are not kept, do nothing return nil
+func (_this *PrototypeScope) Remove(beanName string) *reflect.Value {
+	return nil
+}
+
+// RegisterDestructionCallback prototype beans are not kept, so the callback is never executed
+func (_this *PrototypeScope) RegisterDestructionCallback(beanName string, callback func()) {
+}
+
+// RegisterScope register a custom scope before Freeze, the singleton scope can not be replaced. 注册作用域
+func (_this *BeanFactory) RegisterScope(scopeName string, scope Scope) error {
+	if scopeName == "" || scope == nil {
+		return fmt.Errorf("the scope name and the scope can not be empty")
+	}
+	if _this.IsFrozen() {
+		return &ErrContainerFrozen{Operation: "RegisterScope", BeanName: scopeName}
+	}
+	if scopeName == ScopeSingleton {
+		return fmt.Errorf("the scope '%s' can not be replaced", scopeName)
+	}
+	_this.scopeLock.Lock()
+	defer _this.scopeLock.Unlock()
+	_this.scopes[scopeName] = scope
+	return nil
+}
+
+// GetScope get the registered scope, if it not found return nil
+func (_this *BeanFactory) GetScope(scopeName string) Scope {
+	_this.scopeLock.RLock()
+	defer _this.scopeLock.RUnlock()
+	return _this.scopes[scopeName]
+}
+
+// AddScopedBean add a scoped bean definition, the beanName must not be used by single beans or multi beans.
+// 添加作用域 bean
+func (_this *BeanFactory) AddScopedBean(beanName string, scopedBean *ScopedBean) (isAdd bool, err error) {
+	beanName = BeanNameFilter(beanName)
+	if beanName == "" || scopedBean == nil {
+		return false, nil
+	}
+	if _this.IsFrozen() {
+		return false, &ErrContainerFrozen{Operation: "AddScopedBean", BeanName: beanName}
+	}
+	if scopedBean.Factory == nil {
+		return false, fmt.Errorf("the scoped bean '%s' has no factory function", beanName)
+	}
+	if scopedBean.Scope == "" || scopedBean.Scope == ScopeSingleton {
+		return false, fmt.Errorf("the scoped bean '%s' must not be in the '%s' scope, use SingleBeans or MultiBeans instead", beanName, ScopeSingleton)
+	}
+	if _this.BeanContainer.HasBean(beanName) {
+		return false, fmt.Errorf("this bean '%s' is not a scoped bean , because it is in the container", beanName)
+	}
+	_this.scopeLock.Lock()
+	defer _this.scopeLock.Unlock()
+	if _this.scopedBeans[beanName] != nil { // 已经存在
+		return false, nil
+	}
+	_this.scopedBeans[beanName] = scopedBean
+	return true, nil
+}
+
+// IsScopedBean checks whether the bean is a scoped bean
+func (_this *BeanFactory) IsScopedBean(beanName string) bool {
+	return _this.getScopedDefinition(beanName) != nil || _this.IsAlias(beanName) && _this.getScopedDefinition(_this.CanonicalName(beanName)) != nil
+}
+
+// getScopedDefinition get the definition of the scoped bean, nil if it is not a scoped bean
+func (_this *BeanFactory) getScopedDefinition(beanName string) *ScopedBean {
+	_this.scopeLock.RLock()
+	defer _this.scopeLock.RUnlock()
+	return _this.scopedBeans[BeanNameFilter(beanName)]
+}
+
+// scopedBeanNames get the names of the scoped beans, sorted by name
+func (_this *BeanFactory) scopedBeanNames() []string {
+	_this.scopeLock.RLock()
+	defer _this.scopeLock.RUnlock()
+	names := make([]string, 0, len(_this.scopedBeans))
+	for name := range _this.scopedBeans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scopedBeanType get the ptr type of the scoped bean, by ScopedBean.Type or a raw instance of the Factory.
+// nil if the Factory returns nil
+func (_this *BeanFactory) scopedBeanType(beanName string) reflect.Type {
+	_this.scopeLock.RLock()
+	scopedBean, beanType := _this.scopedBeans[beanName], _this.scopedTypes[beanName]
+	_this.scopeLock.RUnlock()
+	if scopedBean == nil {
+		return nil
+	}
+	if scopedBean.Type != nil {
+		if scopedBean.Type.Kind() != reflect.Ptr {
+			return reflect.PtrTo(scopedBean.Type)
+		}
+		return scopedBean.Type
+	}
+	if beanType != nil {
+		return beanType
+	}
+	raw := scopedBean.Factory()
+	if raw == nil {
+		return nil
+	}
+	beanType = reflect_util.GetPtr(raw).Type()
+	_this.scopeLock.Lock()
+	defer _this.scopeLock.Unlock()
+	_this.scopedTypes[beanName] = beanType
+	return beanType
+}
+
+// getScopedBean get the bean from its scope
+func (_this *BeanFactory) getScopedBean(beanName string, scopedBean *ScopedBean) (*reflect.Value, error) {
+	scope := _this.GetScope(scopedBean.Scope)
+	if scope == nil {
+		return nil, fmt.Errorf("the scope '%s' of the bean '%s' is not registered", scopedBean.Scope, beanName)
+	}
+	return scope.Get(beanName, func() (*reflect.Value, error) {
+		bean, err := _this.createScopedBean(beanName, scopedBean)
+		if err != nil {
+			return nil, err
+		}
+		if destroyHandler, ok := bean.Interface().(DestroyHandler); ok {
+			scope.RegisterDestructionCallback(beanName, func() {
+				_ = destroyHandler.Destroy(_this)
+			})
+		} else if disposableBean, ok := bean.Interface().(DisposableBean); ok {
+			scope.RegisterDestructionCallback(beanName, func() {
+				_ = disposableBean.Destroy()
+			})
+		}
+		return bean, nil
+	})
+}
+
+// createScopedBean create the bean and pass it through the BeanChains, scoped beans are filtered like multi beans
+func (_this *BeanFactory) createScopedBean(beanName string, scopedBean *ScopedBean) (*reflect.Value, error) {
+	raw := scopedBean.Factory()
+	if raw == nil {
+		return nil, fmt.Errorf("the factory of the scoped bean '%s' returns nil", beanName)
+	}
+	ptr := reflect_util.GetPtr(raw)
+	bean := &ptr
+	for _, phase := range []int{BeanCreated, TagInitialized, BeanInjected} {
+		for i := BeanCreated; i < (1 << 3); i++ {
+			if i&phase == 0 {
+				continue
+			}
+			for _, funcStruct := range _this.BeanChains[i] {
+				if funcStruct.Mode != MultiBeanMode && funcStruct.Mode != AllBeanMode {
+					continue
+				}
+				ret, err := funcStruct.Filter(bean)
+				if err != nil {
+					return nil, beanFilterError(beanName, err)
+				}
+				if ret != nil {
+					bean = ret
+				}
+			}
+		}
+	}
+	if initializingBean, ok := bean.Interface().(InitializingBean); ok {
+		if err := initializingBean.AfterPropertiesSet(); err != nil {
+			return nil, fmt.Errorf("failed to initialize the bean '%s': %w", beanName, err)
+		}
+	}
+	return bean, nil
+}
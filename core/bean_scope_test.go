@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/frame"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type scopedDB struct{}
+
+// scopedRequest the prototype bean, calls counts AfterPropertiesSet of all instances
+type scopedRequest struct {
+	DB    *scopedDB `bean:"core_test.scopedDB"`
+	calls *atomic.Int32
+	ready bool
+}
+
+func (r *scopedRequest) AfterPropertiesSet() error {
+	r.calls.Add(1)
+	r.ready = r.DB != nil
+	return nil
+}
+
+type scopedHandler struct {
+	Request *scopedRequest `bean:"request"`
+}
+
+// TestPrototypeScope a new initialized instance on every lookup, by name or by type. the phases do not call
+// AfterPropertiesSet of the scoped bean, it is called once per instance
+func TestPrototypeScope(t *testing.T) {
+	var calls atomic.Int32
+	factory := core.NewBeanFactory()
+	frame.InitFactoryBeanFunc(factory)
+	err := factory.AddBeanConfig(&core.BeanConfig{
+		SingleBeans: []any{&scopedDB{}, &scopedHandler{}},
+		ScopedBeans: map[string]*core.ScopedBean{"request": {
+			Scope:   core.ScopePrototype,
+			Factory: func() any { return &scopedRequest{calls: &calls} },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPhases(t, factory)
+	handler := core.MustGet[*scopedHandler](factory)
+	if handler.Request == nil || !handler.Request.ready {
+		t.Fatal("the scoped bean is not injected and initialized")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("AfterPropertiesSet is called %d times for the injected instance, want once", got)
+	}
+
+	first, err := factory.GetBean("request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := core.GetNamed[*scopedRequest](factory, "request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byType, err := core.Get[*scopedRequest](factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || second == byType || byType == handler.Request {
+		t.Error("the prototype bean is shared")
+	}
+	if !second.ready || !byType.ready || calls.Load() != 4 {
+		t.Errorf("got %d AfterPropertiesSet calls, want once per instance", calls.Load())
+	}
+	if names := factory.GetBeanNamesByType(reflect.TypeOf(&scopedRequest{})); !reflect.DeepEqual(names, []string{"request"}) {
+		t.Errorf("got the names %v by type, want the scoped bean", names)
+	}
+	if !factory.IsScopedBean("request") || !factory.HasBean("request") {
+		t.Error("the scoped bean is not found")
+	}
+}
+
+func TestAddScopedBeanErrors(t *testing.T) {
+	factory := core.NewBeanFactory()
+	if _, err := factory.AddSingleBean(&scopedDB{}); err != nil {
+		t.Fatal(err)
+	}
+	factoryFunc := func() any { return &scopedDB{} }
+	tests := map[string]*core.ScopedBean{
+		"core_test.scopedDB": {Scope: core.ScopePrototype, Factory: factoryFunc}, // in the container
+		"singleton":          {Scope: core.ScopeSingleton, Factory: factoryFunc},
+		"noFactory":          {Scope: core.ScopePrototype},
+	}
+	for beanName, scopedBean := range tests {
+		if added, err := factory.AddScopedBean(beanName, scopedBean); added || err == nil {
+			t.Errorf("AddScopedBean(%q) got %v and the error %v, want an error", beanName, added, err)
+		}
+	}
+	if _, err := factory.AddScopedBean("unregistered", &core.ScopedBean{Scope: "request", Factory: factoryFunc}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := factory.GetBean("unregistered"); err == nil {
+		t.Error("no error for the scope not registered")
+	}
+}
@@ -0,0 +1,476 @@
+package config
+
+import (
+	"fmt"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag the tag of the field bound by the properties, such as `config:"server"`. on a bean field it is the prefix of the
+// properties, on a field of the bound struct it is the key instead of the field name. 配置绑定 tag
+const Tag = "config"
+
+// propertyNode a node of the flattened keys, such as server.hosts[0]
+type propertyNode struct {
+	key      string                   // the full key, such as server.hosts[0]
+	name     string                   // the raw name of the last part, used as the map key
+	hasValue bool                     // the key is a property
+	children map[string]*propertyNode // the normalized name -> child
+	items    map[int]*propertyNode    // the index -> item
+}
+
+// BindProperties create a T and bind the properties under the prefix, see Bind. 按前缀绑定配置
+func BindProperties[T any](env *core.Environment, prefix string) (T, error) {
+	var target T
+	err := Bind(env, prefix, &target)
+	return target, err
+}
+
+// Bind bind the properties under the prefix to the target pointer by the field names. the names are matched relaxedly,
+// such as max-size, max_size and maxSize all match the field MaxSize, and the tag `config:"name"` replaces the field
+// name. the nested structs, pointers, slices (server.hosts[0]) and maps (server.labels.key) are bound recursively, the
+// placeholders in the values are resolved. the property keys not matching any field and the values that can not be
+// converted are reported together in ErrBindProperties. 绑定配置到结构体
+func Bind(env *core.Environment, prefix string, target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("the target of the prefix '%s' must be a non-nil pointer, got %T", prefix, target)
+	}
+	elem := value.Elem()
+	return BindValue(env, prefix, &elem)
+}
+
+// BindValue bind the properties under the prefix to the settable value, see Bind
+func BindValue(env *core.Environment, prefix string, value *reflect.Value) error {
+	if env == nil {
+		return fmt.Errorf("no environment to bind the prefix '%s'", prefix)
+	}
+	binder := &binder{env: env}
+	node := binder.find(newPropertyTree(env.Keys()), prefix)
+	binder.bind(node, prefix, *value)
+	if len(binder.errors) > 0 {
+		return &ErrBindProperties{Prefix: prefix, Errors: binder.errors}
+	}
+	return nil
+}
+
+// binder bind the nodes to the values and collect the errors
+type binder struct {
+	env    *core.Environment
+	errors []BindError
+}
+
+// bind bind the node to the value, node can be nil if there are no keys, then only the leaf properties are looked up
+func (_this *binder) bind(node *propertyNode, key string, value reflect.Value) {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		_this.bindDuration(node, key, value)
+		return
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		if node == nil {
+			return
+		}
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		_this.bind(node, key, value.Elem())
+	case reflect.Struct:
+		_this.bindStruct(node, key, value)
+	case reflect.Slice:
+		_this.bindSlice(node, key, value)
+	case reflect.Map:
+		_this.bindMap(node, key, value)
+	default:
+		if parse.IsSupportBasic(value.Kind()) {
+			_this.bindBasic(node, key, value)
+		} else if node != nil {
+			_this.fail(key, "the type '%s' is not supported", value.Type())
+		}
+	}
+}
+
+// bindStruct bind the fields by name, the embedded structs share the node
+func (_this *binder) bindStruct(node *propertyNode, key string, value reflect.Value) {
+	if node != nil && node.hasValue && len(node.children) == 0 {
+		_this.fail(key, "expected the properties of '%s', got a value", value.Type())
+		return
+	}
+	bound := make(map[string]bool)
+	_this.bindFields(node, key, value, bound)
+	if node == nil {
+		return
+	}
+	for _, name := range sortedKeys(node.children) {
+		if !bound[name] {
+			_this.unbound(node.children[name])
+		}
+	}
+	for _, index := range sortedIndexes(node.items) {
+		_this.unbound(node.items[index])
+	}
+}
+
+// bindFields bind the exported fields, bound is the names of the bound children
+func (_this *binder) bindFields(node *propertyNode, key string, value reflect.Value, bound map[string]bool) {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := field.Tag.Lookup(Tag)
+		if name = strings.TrimSpace(name); ok && name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			fieldValue := value.Field(i)
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(field.Type.Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			_this.bindFields(node, key, fieldValue, bound)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		normalized := normalizeName(name)
+		var child *propertyNode
+		if node != nil {
+			child = node.children[normalized]
+		}
+		bound[normalized] = true
+		_this.bind(child, joinKey(key, kebabCase(name)), value.Field(i))
+	}
+}
+
+// bindSlice bind the items like key[0], or the value like [1,2] or 1,2 converted by the parse package. the indexes must
+// be 0 to n-1, so the length of the slice is the number of the items, not a large index in the config
+func (_this *binder) bindSlice(node *propertyNode, key string, value reflect.Value) {
+	if node == nil {
+		return
+	}
+	if len(node.items) == 0 {
+		if len(node.children) > 0 {
+			_this.fail(key, "expected the items of '%s', got the properties", value.Type())
+		} else if node.hasValue {
+			_this.bindValue(node.key, key, value)
+		}
+		return
+	}
+	indexes := sortedIndexes(node.items)
+	for i, index := range indexes {
+		if index != i {
+			_this.fail(node.items[index].key, "the index %d is not dense, the indexes of '%s' must be 0 to %d", index, key, len(indexes)-1)
+			return
+		}
+	}
+	slice := reflect.MakeSlice(value.Type(), len(indexes), len(indexes))
+	for _, index := range indexes {
+		_this.bind(node.items[index], node.items[index].key, slice.Index(index))
+	}
+	value.Set(slice)
+	for _, name := range sortedKeys(node.children) {
+		_this.unbound(node.children[name])
+	}
+}
+
+// bindMap bind the children like key.name, the raw names are the map keys
+func (_this *binder) bindMap(node *propertyNode, key string, value reflect.Value) {
+	if node == nil {
+		return
+	}
+	if len(node.children) == 0 {
+		if node.hasValue {
+			_this.bindValue(node.key, key, value)
+		}
+		return
+	}
+	mapType := value.Type()
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(mapType))
+	}
+	for _, name := range sortedKeys(node.children) {
+		child := node.children[name]
+		mapKey := reflect.New(mapType.Key()).Elem()
+		if err := convert(child.name, mapKey); err != nil {
+			_this.fail(child.key, "failed to convert the map key '%s' to '%s': %v", child.name, mapType.Key(), err)
+			continue
+		}
+		elem := reflect.New(mapType.Elem()).Elem()
+		if existing := value.MapIndex(mapKey); existing.IsValid() {
+			elem.Set(existing)
+		}
+		before := len(_this.errors)
+		_this.bind(child, child.key, elem)
+		if len(_this.errors) == before {
+			value.SetMapIndex(mapKey, elem)
+		}
+	}
+}
+
+// bindBasic bind the value of the key, if there are no keys, the property of the key is looked up, so the environment
+// variables like SERVER_PORT can be bound
+func (_this *binder) bindBasic(node *propertyNode, key string, value reflect.Value) {
+	switch {
+	case node == nil:
+		if _, ok := _this.env.GetProperty(key); ok {
+			_this.bindValue(key, key, value)
+		}
+	case len(node.children) > 0 || len(node.items) > 0:
+		_this.fail(key, "expected a value of '%s', got the properties", value.Type())
+	case node.hasValue:
+		_this.bindValue(node.key, key, value)
+	}
+}
+
+// bindDuration bind the duration like 10s, or the nanoseconds
+func (_this *binder) bindDuration(node *propertyNode, key string, value reflect.Value) {
+	if node != nil {
+		key = node.key
+	}
+	text, ok, err := _this.property(key)
+	if err != nil || !ok {
+		return
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		nanoseconds, err2 := strconv.ParseInt(text, 10, 64)
+		if err2 != nil {
+			_this.fail(key, "failed to convert '%s' to 'time.Duration': %v", text, err)
+			return
+		}
+		duration = time.Duration(nanoseconds)
+	}
+	value.SetInt(int64(duration))
+}
+
+// bindValue convert the property of the propertyKey and set it to the value
+func (_this *binder) bindValue(propertyKey string, key string, value reflect.Value) {
+	text, ok, err := _this.property(propertyKey)
+	if err != nil || !ok {
+		return
+	}
+	if err = convert(text, value); err != nil {
+		_this.fail(key, "failed to convert '%s' to '%s': %v", text, value.Type(), err)
+	}
+}
+
+// property get the property with the placeholders resolved
+func (_this *binder) property(key string) (string, bool, error) {
+	text, ok := _this.env.GetProperty(key)
+	if !ok {
+		return "", false, nil
+	}
+	resolved, err := _this.env.Resolve(text)
+	if err != nil {
+		_this.fail(key, "%v", err)
+		return "", false, err
+	}
+	return strings.TrimSpace(resolved), true, nil
+}
+
+// convert convert the text to the value by the parse package, the value is not changed on error
+func convert(text string, value reflect.Value) error {
+	kind := value.Kind()
+	converted := reflect.New(value.Type()).Elem()
+	if err := parse.ParseStrSetValue(&kind, &converted, text, 0); err != nil {
+		return err
+	}
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, _ := strconv.ParseInt(text, 10, 64); converted.Int() != n {
+			return strconv.ErrRange
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, _ := strconv.ParseUint(text, 10, 64); converted.Uint() != n {
+			return strconv.ErrRange
+		}
+	}
+	value.Set(converted)
+	return nil
+}
+
+// find find the node of the prefix, the parts are matched relaxedly
+func (_this *binder) find(root *propertyNode, prefix string) *propertyNode {
+	if strings.TrimSpace(prefix) == "" {
+		return root
+	}
+	path, ok := splitPropertyKey(prefix)
+	if !ok {
+		_this.fail(prefix, "invalid prefix")
+		return nil
+	}
+	node := root
+	for _, part := range path {
+		if node == nil {
+			return nil
+		}
+		if index, isIndex := part.(int); isIndex {
+			node = node.items[index]
+		} else {
+			node = node.children[normalizeName(part.(string))]
+		}
+	}
+	return node
+}
+
+// fail add the error of the key
+func (_this *binder) fail(key string, reason string, args ...any) {
+	_this.errors = append(_this.errors, BindError{Key: key, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// unbound add the errors of the keys under the node
+func (_this *binder) unbound(node *propertyNode) {
+	if node.hasValue {
+		_this.fail(node.key, "no field matches the property")
+	}
+	for _, name := range sortedKeys(node.children) {
+		_this.unbound(node.children[name])
+	}
+	for _, index := range sortedIndexes(node.items) {
+		_this.unbound(node.items[index])
+	}
+}
+
+// newPropertyTree build the tree of the keys, the invalid keys are skipped
+func newPropertyTree(keys []string) *propertyNode {
+	root := &propertyNode{}
+	for _, key := range keys {
+		path, ok := splitPropertyKey(key)
+		if !ok {
+			continue
+		}
+		node := root
+		for i, part := range path {
+			node = node.child(part, key, i == len(path)-1)
+		}
+		node.hasValue = true
+	}
+	return root
+}
+
+// child get or create the child of the part, the key of the child is the prefix of fullKey
+func (_this *propertyNode) child(part any, fullKey string, last bool) *propertyNode {
+	var child *propertyNode
+	var ok bool
+	var key string
+	if index, isIndex := part.(int); isIndex {
+		if _this.items == nil {
+			_this.items = make(map[int]*propertyNode)
+		}
+		key = _this.key + "[" + strconv.Itoa(index) + "]"
+		if child, ok = _this.items[index]; !ok {
+			child = &propertyNode{key: key, name: strconv.Itoa(index)}
+			_this.items[index] = child
+		}
+	} else {
+		name := part.(string)
+		if _this.children == nil {
+			_this.children = make(map[string]*propertyNode)
+		}
+		key = joinKey(_this.key, name)
+		if child, ok = _this.children[normalizeName(name)]; !ok {
+			child = &propertyNode{key: key, name: name}
+			_this.children[normalizeName(name)] = child
+		}
+	}
+	if last && !child.hasValue {
+		child.key = fullKey
+	}
+	return child
+}
+
+// splitPropertyKey split the key like server.hosts[0].name into "server", "hosts", 0, "name"
+func splitPropertyKey(key string) ([]any, bool) {
+	var path []any
+	for _, part := range strings.Split(key, ".") {
+		name := part
+		var indexes []any
+		if open := strings.IndexByte(part, '['); open >= 0 {
+			name = part[:open]
+			for rest := part[open:]; rest != ""; {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, false
+				}
+				index, err := strconv.Atoi(rest[1:end])
+				if err != nil || index < 0 {
+					return nil, false
+				}
+				indexes = append(indexes, index)
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" {
+			return nil, false
+		}
+		path = append(path, name)
+		path = append(path, indexes...)
+	}
+	return path, true
+}
+
+// normalizeName the name in lower case without '-' and '_', so max-size, max_size and MaxSize are equal
+func normalizeName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+// kebabCase convert the field name to the kebab case, such as MaxSize -> max-size
+func kebabCase(name string) string {
+	builder := strings.Builder{}
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+				builder.WriteByte('-')
+			}
+			r += 'a' - 'A'
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// joinKey join the prefix and the name by '.'
+func joinKey(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// indirectType the element type of the pointer type
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// sortedKeys the sorted keys of the children
+func sortedKeys(children map[string]*propertyNode) []string {
+	keys := make([]string, 0, len(children))
+	for key := range children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedIndexes the sorted indexes of the items
+func sortedIndexes(items map[int]*propertyNode) []int {
+	indexes := make([]int, 0, len(items))
+	for index := range items {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
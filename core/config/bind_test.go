@@ -0,0 +1,197 @@
+package config_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindDB struct {
+	Name string
+	Port int
+}
+
+type BindBase struct {
+	Version string
+}
+
+type bindServer struct {
+	BindBase
+	MaxSize  int
+	Addr     string `config:"address"`
+	Skipped  string `config:"-"`
+	Timeout  time.Duration
+	Hosts    []string
+	Ports    []int
+	Backends []bindDB
+	Labels   map[string]string
+	Pools    map[string]bindDB
+	DB       *bindDB
+	Ratio    float64
+	Enabled  bool
+}
+
+// bindEnv the environment of the map
+func bindEnv(properties map[string]string) *core.Environment {
+	return core.NewEnvironment(core.NewMapPropertySource("test", properties))
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		want       bindServer
+	}{
+		{"kebab-case", map[string]string{"server.max-size": "1"}, bindServer{MaxSize: 1}},
+		{"snake_case", map[string]string{"server.max_size": "2"}, bindServer{MaxSize: 2}},
+		{"camelCase", map[string]string{"server.maxSize": "3"}, bindServer{MaxSize: 3}},
+		{"upper case", map[string]string{"SERVER.MAXSIZE": "4"}, bindServer{MaxSize: 4}},
+		{"tag name", map[string]string{"server.address": ":80"}, bindServer{Addr: ":80"}},
+		{"embedded struct", map[string]string{"server.version": "v1"}, bindServer{BindBase: BindBase{Version: "v1"}}},
+		{"duration", map[string]string{"server.timeout": "1m30s"}, bindServer{Timeout: 90 * time.Second}},
+		{"duration nanoseconds", map[string]string{"server.timeout": "1500"}, bindServer{Timeout: 1500}},
+		{"float and bool", map[string]string{"server.ratio": "0.5", "server.enabled": "true"}, bindServer{Ratio: 0.5, Enabled: true}},
+		{"slice items", map[string]string{"server.hosts[0]": "a", "server.hosts[1]": "b"}, bindServer{Hosts: []string{"a", "b"}}},
+		{"slice value", map[string]string{"server.ports": "[1,2]"}, bindServer{Ports: []int{1, 2}}},
+		{"slice of structs", map[string]string{"server.backends[0].name": "a", "server.backends[1].port": "2"},
+			bindServer{Backends: []bindDB{{Name: "a"}, {Port: 2}}}},
+		{"map", map[string]string{"server.labels.env": "prod", "server.labels.Zone": "z1"},
+			bindServer{Labels: map[string]string{"env": "prod", "Zone": "z1"}}},
+		{"map of structs", map[string]string{"server.pools.main.name": "m", "server.pools.main.port": "1"},
+			bindServer{Pools: map[string]bindDB{"main": {Name: "m", Port: 1}}}},
+		{"pointer struct", map[string]string{"server.db.name": "main"}, bindServer{DB: &bindDB{Name: "main"}}},
+		{"placeholder", map[string]string{"server.address": "${host}:${port:80}", "host": "local"}, bindServer{Addr: "local:80"}},
+		{"other prefix", map[string]string{"client.max-size": "1"}, bindServer{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got bindServer
+			if err := config.Bind(bindEnv(test.properties), "server", &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBindProperties(t *testing.T) {
+	db, err := config.BindProperties[bindDB](bindEnv(map[string]string{"app.db.name": "main", "app.db.port": "5432"}), "app.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db != (bindDB{Name: "main", Port: 5432}) {
+		t.Errorf("got %+v", db)
+	}
+}
+
+// TestBindEnvironmentVariable the field without a key is looked up by the kebab-case key, so SERVER_MAX_SIZE matches
+func TestBindEnvironmentVariable(t *testing.T) {
+	t.Setenv("SERVER_MAX_SIZE", "7")
+	var got bindServer
+	if err := config.Bind(core.NewEnvironment(core.NewEnvPropertySource()), "server", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxSize != 7 {
+		t.Errorf("got MaxSize %d, want 7", got.MaxSize)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	type target struct {
+		Port    int8
+		DB      bindDB
+		Hosts   []string
+		Codes   map[int]string
+		Timeout time.Duration
+		Ch      chan int
+	}
+	tests := []struct {
+		name       string
+		properties map[string]string
+		want       []config.BindError
+	}{
+		{"unbound key", map[string]string{"app.unknown": "1", "app.db.host": "h"}, []config.BindError{
+			{Key: "app.db.host", Reason: "no field matches the property"},
+			{Key: "app.unknown", Reason: "no field matches the property"},
+		}},
+		{"conversion", map[string]string{"app.port": "abc"}, []config.BindError{
+			{Key: "app.port", Reason: "failed to convert 'abc' to 'int8': strconv.ParseInt: parsing \"abc\": invalid syntax"},
+		}},
+		{"overflow", map[string]string{"app.port": "300"}, []config.BindError{
+			{Key: "app.port", Reason: "failed to convert '300' to 'int8': value out of range"},
+		}},
+		{"value of struct", map[string]string{"app.db": "x"}, []config.BindError{
+			{Key: "app.db", Reason: "expected the properties of 'config_test.bindDB', got a value"},
+		}},
+		{"properties of value", map[string]string{"app.port.x": "1"}, []config.BindError{
+			{Key: "app.port", Reason: "expected a value of 'int8', got the properties"},
+		}},
+		{"properties of slice", map[string]string{"app.hosts.x": "1"}, []config.BindError{
+			{Key: "app.hosts", Reason: "expected the items of '[]string', got the properties"},
+		}},
+		{"large index", map[string]string{"app.hosts[2000000000]": "x"}, []config.BindError{
+			{Key: "app.hosts[2000000000]", Reason: "the index 2000000000 is not dense, the indexes of 'app.hosts' must be 0 to 0"},
+		}},
+		{"sparse index", map[string]string{"app.hosts[0]": "a", "app.hosts[2]": "c"}, []config.BindError{
+			{Key: "app.hosts[2]", Reason: "the index 2 is not dense, the indexes of 'app.hosts' must be 0 to 1"},
+		}},
+		{"map key", map[string]string{"app.codes.x": "1"}, []config.BindError{
+			{Key: "app.codes.x", Reason: "failed to convert the map key 'x' to 'int': strconv.ParseInt: parsing \"x\": invalid syntax"},
+		}},
+		{"duration", map[string]string{"app.timeout": "soon"}, []config.BindError{
+			{Key: "app.timeout", Reason: "failed to convert 'soon' to 'time.Duration': time: invalid duration \"soon\""},
+		}},
+		{"placeholder", map[string]string{"app.port": "${missing}"}, []config.BindError{
+			{Key: "app.port", Reason: "ErrPropertyNotFound : the property 'missing' is not found and has no default value"},
+		}},
+		{"unsupported type", map[string]string{"app.ch": "1"}, []config.BindError{
+			{Key: "app.ch", Reason: "the type 'chan int' is not supported"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got target
+			err := config.Bind(bindEnv(test.properties), "app", &got)
+			var bindErr *config.ErrBindProperties
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("got the error %v, want ErrBindProperties", err)
+			}
+			if bindErr.Prefix != "app" {
+				t.Errorf("got the prefix %q", bindErr.Prefix)
+			}
+			if !reflect.DeepEqual(bindErr.Errors, test.want) {
+				t.Errorf("got the errors %+v, want %+v", bindErr.Errors, test.want)
+			}
+		})
+	}
+}
+
+// TestBindLargeIndex a large index is reported without allocating the slice
+func TestBindLargeIndex(t *testing.T) {
+	var got struct{ Hosts []string }
+	err := config.Bind(bindEnv(map[string]string{"app.hosts[0]": "a", "app.hosts[2000000000]": "x"}), "app", &got)
+	var bindErr *config.ErrBindProperties
+	if !errors.As(err, &bindErr) || len(bindErr.Errors) != 1 || bindErr.Errors[0].Key != "app.hosts[2000000000]" {
+		t.Fatalf("got the error %v, want the error of the index", err)
+	}
+	if got.Hosts != nil {
+		t.Errorf("the slice of %d items is allocated", len(got.Hosts))
+	}
+}
+
+func TestBindInvalidTarget(t *testing.T) {
+	var target bindDB
+	for _, invalid := range []any{target, (*bindDB)(nil)} {
+		if err := config.Bind(bindEnv(nil), "app", invalid); err == nil {
+			t.Errorf("no error for the target %T", invalid)
+		}
+	}
+	if err := config.BindValue(nil, "app", nil); err == nil {
+		t.Error("no error for the nil environment")
+	}
+}
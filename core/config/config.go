@@ -0,0 +1,216 @@
+// Package config loads the config files into the property sources of core.Environment. 配置文件加载
+//
+// the supported files are application.properties, application.yaml (or .yml), application.toml, application.json and
+// .env, and the profile-specific files application-{profile}.* override them. the nested keys are flattened to dotted
+// keys, such as server.port, and the list items are indexed, such as servers[0].host.
+//
+// the precedence, from the highest to the lowest:
+//
+//  1. the command-line arguments, such as --server.port=9090
+//  2. the environment variables, such as SERVER_PORT=9090
+//  3. the sources added by application.WithPropertySources, in their order
+//  4. application-{profile}.*, the later active profile wins
+//  5. application.*
+//  6. .env
+//
+// within the files of the same name, .properties > .yaml > .yml > .toml > .json. AddDir adds the files after the
+// existing sources, which keeps this order, see the README for the details.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/se/file_util"
+	"github.com/cutexingluo/go-spring/core"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	BaseName    = "application" // the base name of the config files
+	DotEnvName  = ".env"        // the name of the dotenv file
+	profileJoin = "-"           // application-{profile}
+)
+
+// Loader parse the content of a config file into the flattened properties
+type Loader func(data []byte) (map[string]string, error)
+
+// Extensions the extensions of the config files, in the order of precedence
+var Extensions = []string{".properties", ".yaml", ".yml", ".toml", ".json"}
+
+var loaderLock sync.RWMutex
+var loaders = map[string]Loader{
+	".properties": LoadProperties,
+	".yaml":       LoadYAML,
+	".yml":        LoadYAML,
+	".toml":       LoadTOML,
+	".json":       LoadJSON,
+	".env":        LoadDotEnv,
+}
+
+// RegisterLoader register the loader of the extension, such as ".ini", it replaces the existing loader.
+// the extension should also be added to Extensions to be loaded by LoadDir. 注册加载器
+func RegisterLoader(ext string, loader Loader) {
+	loaderLock.Lock()
+	defer loaderLock.Unlock()
+	loaders[strings.ToLower(ext)] = loader
+}
+
+// GetLoader get the loader of the extension
+func GetLoader(ext string) (Loader, bool) {
+	loaderLock.RLock()
+	defer loaderLock.RUnlock()
+	loader, ok := loaders[strings.ToLower(ext)]
+	return loader, ok
+}
+
+// LoadFile load the config file by the loader of its extension, the source is named by the path. 加载配置文件
+func LoadFile(path string) (core.PropertySource, error) {
+	file := file_util.NewFile(path)
+	loader, ok := GetLoader(file.GetFileExt())
+	if !ok {
+		return nil, fmt.Errorf("no loader for the config file '%s'", path)
+	}
+	data, err := file.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	properties, err := loader(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the config file '%s': %w", path, err)
+	}
+	if file.GetFileName() == DotEnvName {
+		return &dotEnvPropertySource{MapPropertySource: core.NewMapPropertySource(path, properties)}, nil
+	}
+	return core.NewMapPropertySource(path, properties), nil
+}
+
+// ConfigFiles get the existing config files in the dir, in the order of precedence, see the package doc
+func ConfigFiles(dir string, profiles []string) []string {
+	var names []string
+	for i := len(profiles) - 1; i >= 0; i-- {
+		for _, ext := range Extensions {
+			names = append(names, BaseName+profileJoin+profiles[i]+ext)
+		}
+	}
+	for _, ext := range Extensions {
+		names = append(names, BaseName+ext)
+	}
+	names = append(names, DotEnvName)
+	var files []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if isFile, err := file_util.NewFile(path).IsFile(); err == nil && isFile {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// LoadDir load the config files in the dir, in the order of precedence. 加载目录中的配置文件
+func LoadDir(dir string, profiles []string) ([]core.PropertySource, error) {
+	var sources []core.PropertySource
+	for _, path := range ConfigFiles(dir, profiles) {
+		source, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// AddDir load the config files in the dir with the active profiles of the factory, and add them to the Environment of
+// the factory after the existing sources. the files loaded before are replaced. 添加目录中的配置文件到工厂的环境
+func AddDir(factory *core.BeanFactory, dir string) error {
+	sources, err := LoadDir(dir, factory.ActiveProfiles())
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		factory.Environment().AddLast(source)
+	}
+	return nil
+}
+
+// AddExecutableDir load the config files next to the executable, see AddDir
+func AddExecutableDir(factory *core.BeanFactory) error {
+	dir, err := file_util.GetExecutableDirPath()
+	if err != nil {
+		return err
+	}
+	return AddDir(factory, dir)
+}
+
+// LoadProperties load the properties format, see core.ParseProperties
+func LoadProperties(data []byte) (map[string]string, error) {
+	return core.ParseProperties(strings.NewReader(string(data)))
+}
+
+// LoadJSON load the json object, the nested keys are flattened
+func LoadJSON(data []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var tree map[string]any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	properties := make(map[string]string)
+	Flatten("", tree, properties)
+	return properties, nil
+}
+
+// Flatten flatten the tree of maps and slices into the properties with the prefix, such as
+// {"server": {"hosts": ["a"]}} -> server.hosts[0]=a. an empty map or slice is an empty value. 展开嵌套的 key
+func Flatten(prefix string, value any, properties map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			properties[prefix] = ""
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prefix != "" {
+				Flatten(prefix+"."+key, v[key], properties)
+			} else {
+				Flatten(key, v[key], properties)
+			}
+		}
+	case []any:
+		if len(v) == 0 {
+			properties[prefix] = ""
+		}
+		for index, item := range v {
+			Flatten(prefix+"["+strconv.Itoa(index)+"]", item, properties)
+		}
+	case nil:
+		properties[prefix] = ""
+	case string:
+		properties[prefix] = v
+	case float64:
+		properties[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		properties[prefix] = fmt.Sprint(v)
+	}
+}
+
+// dotEnvPropertySource the source of the .env file, the key server.port also matches SERVER_PORT like the environment
+// variables
+type dotEnvPropertySource struct {
+	*core.MapPropertySource
+}
+
+// GetProperty get the property by the key, or by the environment variable name of the key
+func (_this *dotEnvPropertySource) GetProperty(key string) (string, bool) {
+	if value, ok := _this.MapPropertySource.GetProperty(key); ok {
+		return value, true
+	}
+	return _this.MapPropertySource.GetProperty(core.ToEnvKey(key))
+}
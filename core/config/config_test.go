@@ -0,0 +1,188 @@
+package config_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loaderTest a document and the properties or the line of the syntax error
+type loaderTest struct {
+	name      string
+	data      string
+	want      map[string]string
+	errorLine int // the line of the ErrConfigSyntax, 0 is no error
+}
+
+// runLoaderTests run the tests of the loader
+func runLoaderTests(t *testing.T, loader config.Loader, tests []loaderTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := loader([]byte(test.data))
+			if test.errorLine > 0 {
+				var syntaxErr *config.ErrConfigSyntax
+				if !errors.As(err, &syntaxErr) {
+					t.Fatalf("got the error %v and %v, want ErrConfigSyntax at line %d", err, got, test.errorLine)
+				}
+				if syntaxErr.Line != test.errorLine {
+					t.Errorf("got the error at line %d, want line %d: %v", syntaxErr.Line, test.errorLine, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// writeFiles write the files to a temp dir, returns the dir
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadProperties(t *testing.T) {
+	got, err := config.LoadProperties([]byte("# comment\n! comment\nserver.port=8080\nserver.host: local\nlong = a\\\n  b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"server.port": "8080", "server.host": "local", "long": "ab"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err = config.LoadProperties([]byte("a=1\ninvalid\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got the error %v, want the error at line 2", err)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	got, err := config.LoadJSON([]byte(`{"server": {"port": 8080, "ratio": 0.5, "hosts": ["a", "b"], "tls": true,
+		"none": null, "empty": {}, "list": []}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"server.port": "8080", "server.ratio": "0.5", "server.hosts[0]": "a", "server.hosts[1]": "b",
+		"server.tls": "true", "server.none": "", "server.empty": "", "server.list": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err = config.LoadJSON([]byte(`["not an object"]`)); err == nil {
+		t.Error("no error for the json array")
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tree := map[string]any{
+		"a": map[string]any{"b": []any{map[string]any{"c": "1"}, []any{"x"}}},
+		"f": 1.5,
+		"n": 2,
+	}
+	got := make(map[string]string)
+	config.Flatten("root", tree, got)
+	want := map[string]string{"root.a.b[0].c": "1", "root.a.b[1][0]": "x", "root.f": "1.5", "root.n": "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.ini": "a=1", ".env": "SERVER_PORT=9090"})
+	if _, err := config.LoadFile(filepath.Join(dir, "application.ini")); err == nil {
+		t.Error("no error for the extension without a loader")
+	}
+	source, err := config.LoadFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := source.GetProperty("server.port"); !ok || value != "9090" {
+		t.Errorf("the .env source got server.port %q %v, want 9090", value, ok)
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"application.yaml": "", "application.properties": "", "application.json": "{}", ".env": "",
+		"application-dev.yml": "", "application-test.toml": "", "application-prod.yaml": "", "other.yaml": "",
+	})
+	var got []string
+	for _, path := range config.ConfigFiles(dir, []string{"dev", "test"}) {
+		got = append(got, filepath.Base(path))
+	}
+	want := []string{"application-test.toml", "application-dev.yml", "application.properties", "application.yaml",
+		"application.json", ".env"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestLoadDirPrecedence the later profile wins, the profile files win over application.*, .properties wins over .yaml,
+// and .env is the last
+func TestLoadDirPrecedence(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"application.properties":  "a=properties\nb=properties\n",
+		"application.yaml":        "a: yaml\nb: yaml\nc: yaml\n",
+		"application.json":        `{"c": "json", "d": "json"}`,
+		"application-dev.yaml":    "e: dev\nf: dev\n",
+		"application-test.toml":   "f = \"test\"\n",
+		".env":                    "D=env\nG=env\n",
+		"application-unused.yaml": "g: unused\n",
+		"application-dev.yml.bak": "e: bak\n",
+	})
+	sources, err := config.LoadDir(dir, []string{"dev", "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnvironment(sources...)
+	want := map[string]string{"a": "properties", "b": "properties", "c": "yaml", "d": "json", "e": "dev", "f": "test",
+		"g": "env"}
+	for key, value := range want {
+		if got, _ := env.GetProperty(key); got != value {
+			t.Errorf("got %s=%q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestLoadDirError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.yaml": "a: 1\n b: 2\n"})
+	_, err := config.LoadDir(dir, nil)
+	var syntaxErr *config.ErrConfigSyntax
+	if !errors.As(err, &syntaxErr) || syntaxErr.Format != "yaml" || syntaxErr.Line != 2 {
+		t.Errorf("got the error %v, want the yaml error at line 2", err)
+	}
+}
+
+// TestAddDir the config files are added after the existing sources, so the command-line arguments win
+func TestAddDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.properties": "a=file\nb=file\n", "application-dev.properties": "b=dev\n"})
+	factory := core.NewBeanFactory()
+	factory.Environment().AddFirst(core.NewMapPropertySource("args", map[string]string{"a": "args"}))
+	if err := factory.SetActiveProfiles("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddDir(factory, dir); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"a": "args", "b": "dev"} {
+		if got, _ := factory.Environment().GetProperty(key); got != value {
+			t.Errorf("got %s=%q, want %q", key, got, value)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LoadDotEnv load the .env format, such as KEY=value or export KEY="value". the double-quoted values are unescaped, the
+// single-quoted values are literal, and the unquoted values end at " #". 加载 .env 文件
+func LoadDotEnv(data []byte) (map[string]string, error) {
+	properties := make(map[string]string)
+	for index, text := range strings.Split(string(data), "\n") {
+		line := index + 1
+		text = strings.TrimSpace(text)
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		key, value, found := strings.Cut(text, "=")
+		if key = strings.TrimSpace(key); !found || key == "" {
+			return nil, syntaxError("dotenv", line, "expected KEY=value, got '%s'", text)
+		}
+		value, err := dotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, syntaxError("dotenv", line, "%v", err)
+		}
+		properties[key] = value
+	}
+	return properties, nil
+}
+
+// dotEnvValue unquote the value, or remove the comment of the unquoted value
+func dotEnvValue(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	switch value[0] {
+	case '"':
+		end := closingQuote(value, '"')
+		if end < 0 {
+			return "", strconv.ErrSyntax
+		}
+		return strconv.Unquote(value[:end+1])
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", strconv.ErrSyntax
+		}
+		return value[1 : end+1], nil
+	}
+	return stripComment(value), nil
+}
+
+// closingQuote find the index of the quote closing the string at 0, the escaped quotes are skipped
+func closingQuote(text string, quote byte) int {
+	for i := 1; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+		} else if text[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment remove the comment beginning with " #" of the unquoted value
+func stripComment(value string) string {
+	if index := strings.Index(value, " #"); index >= 0 {
+		value = value[:index]
+	}
+	return strings.TrimSpace(value)
+}
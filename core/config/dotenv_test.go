@@ -0,0 +1,20 @@
+package config_test
+
+import (
+	"github.com/cutexingluo/go-spring/core/config"
+	"testing"
+)
+
+func TestLoadDotEnv(t *testing.T) {
+	runLoaderTests(t, config.LoadDotEnv, []loaderTest{
+		{name: "values", data: "# comment\n\nA=1\nexport B = two # comment\nC=\nD=a#b\n",
+			want: map[string]string{"A": "1", "B": "two", "C": "", "D": "a#b"}},
+		{name: "quotes", data: "A=\"x\\ny \\\" # z\" # comment\nB='lit\\n # x'\n",
+			want: map[string]string{"A": "x\ny \" # z", "B": "lit\\n # x"}},
+		{name: "no equal", data: "A=1\nB\n", errorLine: 2},
+		{name: "empty key", data: "=1\n", errorLine: 1},
+		{name: "unclosed double quote", data: "A=1\nB=\"x\n", errorLine: 2},
+		{name: "unclosed single quote", data: "A='x\n", errorLine: 1},
+		{name: "invalid escape", data: "A=\"\\q\"\n", errorLine: 1},
+	})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrConfigSyntax the config file is invalid. 配置文件语法错误
+type ErrConfigSyntax struct {
+	error
+	Format string // the format of the file, such as yaml
+	Line   int    // the line number, from 1
+	Reason string // the reason
+}
+
+func (e *ErrConfigSyntax) Error() string {
+	return fmt.Sprintf("ErrConfigSyntax : invalid %s at line %d: %s", e.Format, e.Line, e.Reason)
+}
+
+// syntaxError create the ErrConfigSyntax
+func syntaxError(format string, line int, reason string, args ...any) *ErrConfigSyntax {
+	return &ErrConfigSyntax{Format: format, Line: line, Reason: fmt.Sprintf(reason, args...)}
+}
+
+// BindError the error of a property key in Bind
+type BindError struct {
+	Key    string // the property key, such as server.port
+	Reason string // the reason, such as the conversion error
+}
+
+// ErrBindProperties all errors of the properties in Bind. 配置绑定错误
+type ErrBindProperties struct {
+	error
+	Prefix string      // the prefix of the properties
+	Errors []BindError // the unbound or unparseable keys
+}
+
+func (e *ErrBindProperties) Error() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("ErrBindProperties : failed to bind %d properties of the prefix '%s':", len(e.Errors), e.Prefix))
+	for _, bindError := range e.Errors {
+		builder.WriteString(fmt.Sprintf("\n  '%s': %s", bindError.Key, bindError.Reason))
+	}
+	return builder.String()
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// flowParser parse the inline values, such as the yaml flow collections [a, b] and {a: 1}, or the toml arrays and
+// inline tables [1, 2] and {a = 1}
+type flowParser struct {
+	format string // yaml or toml
+	line   int    // the line number of the value
+	text   string
+	pos    int
+	keySep byte // the separator of the key and the value in a map, ':' or '='
+}
+
+// newFlowParser create the parser of the text
+func newFlowParser(format string, line int, text string, keySep byte) *flowParser {
+	return &flowParser{format: format, line: line, text: text, keySep: keySep}
+}
+
+// parseValue parse a list, a map, a quoted string or a plain scalar
+func (_this *flowParser) parseValue() (any, error) {
+	_this.skipSpaces()
+	if _this.pos >= len(_this.text) {
+		return nil, _this.error("expected a value")
+	}
+	switch _this.text[_this.pos] {
+	case '[':
+		return _this.parseList()
+	case '{':
+		return _this.parseMap()
+	case '"', '\'':
+		return _this.parseQuoted()
+	default:
+		return _this.plainValue(_this.parsePlain(",]}")), nil
+	}
+}
+
+// parseList parse the list like [a, b], a trailing comma is allowed
+func (_this *flowParser) parseList() (any, error) {
+	_this.pos++ // [
+	items := make([]any, 0)
+	for {
+		_this.skipSpaces()
+		if _this.pos >= len(_this.text) {
+			return nil, _this.error("the list is not closed")
+		}
+		if _this.text[_this.pos] == ']' {
+			_this.pos++
+			return items, nil
+		}
+		item, err := _this.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if err = _this.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMap parse the map like {a: 1, b: 2}
+func (_this *flowParser) parseMap() (any, error) {
+	_this.pos++ // {
+	m := make(map[string]any)
+	for {
+		_this.skipSpaces()
+		if _this.pos >= len(_this.text) {
+			return nil, _this.error("the map is not closed")
+		}
+		if _this.text[_this.pos] == '}' {
+			_this.pos++
+			return m, nil
+		}
+		var key string
+		if c := _this.text[_this.pos]; c == '"' || c == '\'' {
+			quoted, err := _this.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted.(string)
+		} else {
+			key = _this.parsePlain(string(_this.keySep) + ",}")
+		}
+		_this.skipSpaces()
+		if key == "" || _this.pos >= len(_this.text) || _this.text[_this.pos] != _this.keySep {
+			return nil, _this.error("expected 'key %c value' in the map", _this.keySep)
+		}
+		_this.pos++ // keySep
+		value, err := _this.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, _this.error("duplicate key '%s'", key)
+		}
+		m[key] = value
+		if err = _this.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator skip the ',' after an item, or stop before the end
+func (_this *flowParser) separator(end byte) error {
+	_this.skipSpaces()
+	if _this.pos < len(_this.text) {
+		switch _this.text[_this.pos] {
+		case ',':
+			_this.pos++
+			return nil
+		case end:
+			return nil
+		}
+	}
+	return _this.error("expected ',' or '%c'", end)
+}
+
+// parseQuoted parse the double-quoted string with escapes, or the single-quoted string.
+// the single quote is escaped by two single quotes in yaml, and the single-quoted string is literal in toml.
+func (_this *flowParser) parseQuoted() (any, error) {
+	text := _this.text[_this.pos:]
+	if text[0] == '"' {
+		end := closingQuote(text, '"')
+		if end < 0 {
+			return nil, _this.error("the string is not closed")
+		}
+		value, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, _this.error("invalid string %s", text[:end+1])
+		}
+		_this.pos += end + 1
+		return value, nil
+	}
+	builder := strings.Builder{}
+	for i := 1; i < len(text); i++ {
+		if text[i] != '\'' {
+			builder.WriteByte(text[i])
+		} else if _this.format == "yaml" && i+1 < len(text) && text[i+1] == '\'' {
+			builder.WriteByte('\'')
+			i++
+		} else {
+			_this.pos += i + 1
+			return builder.String(), nil
+		}
+	}
+	return nil, _this.error("the string is not closed")
+}
+
+// parsePlain read the plain text until one of the stops
+func (_this *flowParser) parsePlain(stops string) string {
+	start := _this.pos
+	for _this.pos < len(_this.text) && strings.IndexByte(stops, _this.text[_this.pos]) < 0 {
+		_this.pos++
+	}
+	return strings.TrimSpace(_this.text[start:_this.pos])
+}
+
+// plainValue convert the plain scalar, null and ~ are nil in yaml, and the underscores of the toml numbers are removed
+func (_this *flowParser) plainValue(text string) any {
+	if _this.format == "yaml" {
+		if text == "~" || text == "null" || text == "Null" || text == "NULL" {
+			return nil
+		}
+		return text
+	}
+	if strings.Contains(text, "_") && strings.Trim(text, "+-0123456789._eExobabcdefABCDEF") == "" {
+		return strings.ReplaceAll(text, "_", "")
+	}
+	return text
+}
+
+// end checks that only spaces or a comment are left
+func (_this *flowParser) end() error {
+	_this.skipSpaces()
+	if _this.pos < len(_this.text) && _this.text[_this.pos] != '#' {
+		return _this.error("unexpected '%s' after the value", _this.text[_this.pos:])
+	}
+	return nil
+}
+
+// skipSpaces skip the spaces, tabs and line breaks
+func (_this *flowParser) skipSpaces() {
+	for _this.pos < len(_this.text) && strings.IndexByte(" \t\r\n", _this.text[_this.pos]) >= 0 {
+		_this.pos++
+	}
+}
+
+// error create the syntax error at the line of the value
+func (_this *flowParser) error(reason string, args ...any) error {
+	return syntaxError(_this.format, _this.line, reason, args...)
+}
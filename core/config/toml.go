@@ -0,0 +1,207 @@
+package config
+
+import (
+	"strings"
+)
+
+// LoadTOML load the toml subset: the tables [a.b], the arrays of tables [[a]], the dotted and quoted keys, the basic and
+// literal strings, the arrays across lines and the inline tables. the multi-line strings are not supported, and the
+// numbers, booleans and dates are kept as they are written. the nested keys are flattened. 加载 toml 文件
+func LoadTOML(data []byte) (map[string]string, error) {
+	root := make(map[string]any)
+	current := root
+	lines := strings.Split(string(data), "\n")
+	for index := 0; index < len(lines); index++ {
+		number := index + 1
+		text := strings.TrimSpace(stripTOMLComment(lines[index]))
+		if text == "" {
+			continue
+		}
+		var err error
+		switch {
+		case strings.HasPrefix(text, "[["):
+			if !strings.HasSuffix(text, "]]") {
+				return nil, syntaxError("toml", number, "the array of tables '%s' is not closed", text)
+			}
+			current, err = appendTOMLTable(root, text[2:len(text)-2], number)
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return nil, syntaxError("toml", number, "the table '%s' is not closed", text)
+			}
+			var path []string
+			if path, err = splitTOMLKey(text[1:len(text)-1], number); err == nil {
+				current, err = tomlTable(root, path, number)
+			}
+		default:
+			for tomlDepth(text) > 0 && index+1 < len(lines) { // the array or the inline table continues
+				index++
+				text += " " + strings.TrimSpace(stripTOMLComment(lines[index]))
+			}
+			err = setTOMLValue(current, text, number)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	properties := make(map[string]string)
+	Flatten("", root, properties)
+	return properties, nil
+}
+
+// setTOMLValue parse "key = value" and set it to the table
+func setTOMLValue(table map[string]any, text string, number int) error {
+	index := indexOutsideQuotes(text, '=')
+	if index < 0 {
+		return syntaxError("toml", number, "expected 'key = value', got '%s'", text)
+	}
+	path, err := splitTOMLKey(text[:index], number)
+	if err != nil {
+		return err
+	}
+	valueText := strings.TrimSpace(text[index+1:])
+	if strings.HasPrefix(valueText, `"""`) || strings.HasPrefix(valueText, "'''") {
+		return syntaxError("toml", number, "multi-line strings are not supported")
+	}
+	parser := newFlowParser("toml", number, valueText, '=')
+	value, err := parser.parseValue()
+	if err != nil {
+		return err
+	}
+	if err = parser.end(); err != nil {
+		return err
+	}
+	if table, err = tomlTable(table, path[:len(path)-1], number); err != nil {
+		return err
+	}
+	key := path[len(path)-1]
+	if _, ok := table[key]; ok {
+		return syntaxError("toml", number, "duplicate key '%s'", key)
+	}
+	table[key] = value
+	return nil
+}
+
+// appendTOMLTable append a new table to the array of tables [[a.b]]
+func appendTOMLTable(root map[string]any, keyText string, number int) (map[string]any, error) {
+	path, err := splitTOMLKey(keyText, number)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := tomlTable(root, path[:len(path)-1], number)
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	array, ok := parent[key].([]any)
+	if !ok && parent[key] != nil {
+		return nil, syntaxError("toml", number, "'%s' is not an array of tables", keyText)
+	}
+	table := make(map[string]any)
+	parent[key] = append(array, table)
+	return table, nil
+}
+
+// tomlTable get or create the table of the path, the last table of an array of tables is used
+func tomlTable(table map[string]any, path []string, number int) (map[string]any, error) {
+	for _, key := range path {
+		switch child := table[key].(type) {
+		case nil:
+			next := make(map[string]any)
+			table[key] = next
+			table = next
+		case map[string]any:
+			table = child
+		case []any:
+			last, ok := child[len(child)-1].(map[string]any)
+			if !ok {
+				return nil, syntaxError("toml", number, "'%s' is not a table", key)
+			}
+			table = last
+		default:
+			return nil, syntaxError("toml", number, "'%s' is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// splitTOMLKey split the dotted key, the parts can be quoted, such as a."b.c"
+func splitTOMLKey(text string, number int) ([]string, error) {
+	var path []string
+	parser := newFlowParser("toml", number, strings.TrimSpace(text), '=')
+	for {
+		parser.skipSpaces()
+		if parser.pos >= len(parser.text) {
+			return nil, syntaxError("toml", number, "invalid key '%s'", text)
+		}
+		var key string
+		if c := parser.text[parser.pos]; c == '"' || c == '\'' {
+			quoted, err := parser.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted.(string)
+		} else if key = parser.parsePlain("."); key == "" || strings.ContainsAny(key, " \t") {
+			return nil, syntaxError("toml", number, "invalid key '%s'", text)
+		}
+		path = append(path, key)
+		parser.skipSpaces()
+		if parser.pos >= len(parser.text) {
+			return path, nil
+		}
+		if parser.text[parser.pos] != '.' {
+			return nil, syntaxError("toml", number, "invalid key '%s'", text)
+		}
+		parser.pos++
+	}
+}
+
+// stripTOMLComment remove the comment outside the strings
+func stripTOMLComment(text string) string {
+	if index := indexOutsideQuotes(text, '#'); index >= 0 {
+		return text[:index]
+	}
+	return text
+}
+
+// tomlDepth the count of the brackets and braces not closed, outside the strings
+func tomlDepth(text string) int {
+	depth := 0
+	walkOutsideQuotes(text, func(index int) bool {
+		switch text[index] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+		return false
+	})
+	return depth
+}
+
+// indexOutsideQuotes the index of the first c outside the strings, or -1
+func indexOutsideQuotes(text string, c byte) int {
+	return walkOutsideQuotes(text, func(index int) bool {
+		return text[index] == c
+	})
+}
+
+// walkOutsideQuotes call f with the index of each byte outside the strings until f returns true, returns the index
+// or -1. the double-quoted strings have escapes, the single-quoted strings are literal.
+func walkOutsideQuotes(text string, f func(index int) bool) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote != 0:
+			if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			quote = text[i]
+		case f(i):
+			return i
+		}
+	}
+	return -1
+}
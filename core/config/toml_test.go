@@ -0,0 +1,38 @@
+package config_test
+
+import (
+	"github.com/cutexingluo/go-spring/core/config"
+	"testing"
+)
+
+func TestLoadTOML(t *testing.T) {
+	runLoaderTests(t, config.LoadTOML, []loaderTest{
+		{name: "empty", data: "# comment\n\n", want: map[string]string{}},
+		{name: "values", data: "name = \"app\" # comment\nport = 8080\nbig = 1_000\nratio = 0.5\ntls = true\nday = 2024-01-02\n",
+			want: map[string]string{"name": "app", "port": "8080", "big": "1000", "ratio": "0.5", "tls": "true",
+				"day": "2024-01-02"}},
+		{name: "strings", data: "a = \"x\\ty # not a comment\"\nb = 'C:\\path'\nc = \"\\u00e9\"\n",
+			want: map[string]string{"a": "x\ty # not a comment", "b": "C:\\path", "c": "é"}},
+		{name: "nested tables", data: "top = 1\n[server]\nport = 80\n[server.tls]\nenabled = true\n[client]\nport = 81\n",
+			want: map[string]string{"top": "1", "server.port": "80", "server.tls.enabled": "true", "client.port": "81"}},
+		{name: "dotted and quoted keys", data: "a.b = 1\n\"c.d\" = 2\n[e.'f g']\nh = 3\n",
+			want: map[string]string{"a.b": "1", "c.d": "2", "e.f g.h": "3"}},
+		{name: "array of tables", data: "[[servers]]\nhost = \"a\"\n[servers.tls]\non = true\n[[servers]]\nhost = \"b\"\n",
+			want: map[string]string{"servers[0].host": "a", "servers[0].tls.on": "true", "servers[1].host": "b"}},
+		{name: "arrays across lines", data: "hosts = [\n  \"a\", # first\n  \"b\",\n]\nnext = 1\n",
+			want: map[string]string{"hosts[0]": "a", "hosts[1]": "b", "next": "1"}},
+		{name: "inline table", data: "db = {name = \"main\", pool = {size = 8}, tags = [1, 2]}\n",
+			want: map[string]string{"db.name": "main", "db.pool.size": "8", "db.tags[0]": "1", "db.tags[1]": "2"}},
+		{name: "no equal", data: "a = 1\nb\n", errorLine: 2},
+		{name: "unclosed table", data: "[a\n", errorLine: 1},
+		{name: "unclosed array of tables", data: "[[a]\n", errorLine: 1},
+		{name: "duplicate key", data: "a = 1\n[t]\nb = 1\nb = 2\n", errorLine: 4},
+		{name: "multi-line string", data: "a = \"\"\"\nx\n\"\"\"\n", errorLine: 1},
+		{name: "not a table", data: "a = 1\n[a.b]\n", errorLine: 2},
+		{name: "not an array of tables", data: "[a]\n[[a]]\n", errorLine: 2},
+		{name: "invalid key", data: "a b = 1\n", errorLine: 1},
+		{name: "empty key part", data: "a. = 1\n", errorLine: 1},
+		{name: "after value", data: "a = \"x\" y\n", errorLine: 1},
+		{name: "unclosed string", data: "a = 'x\n", errorLine: 1},
+	})
+}
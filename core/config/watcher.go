@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/se/file_util"
+	"github.com/cutexingluo/go-spring/core"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConfigChangedEvent the event published after the config files are reloaded. 配置变更事件
+type ConfigChangedEvent struct {
+	Keys  []string // the keys whose effective values changed, added or removed, sorted
+	Files []string // the config files modified, added or removed, sorted
+}
+
+// ConfigChangedListener the bean notified after the config files are reloaded and the refresh fields are initialized.
+// 配置变更监听器
+type ConfigChangedListener interface {
+	OnConfigChanged(event *ConfigChangedEvent)
+}
+
+// fileState the state of a config file, the file is reloaded if it changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher poll the config files of a dir and reload them into the Environment of the factory when they change. then the
+// beans are refreshed by Refresh, and the ConfigChangedListener beans are notified. 配置文件监视器
+type Watcher struct {
+	// Interval the interval of the polling, default 5s
+	Interval time.Duration
+	// Refresh re-initialize the bean in place after the reload, such as bean_init.RefreshWith for the fields tagged
+	// `refresh:"true"`. nil does nothing
+	Refresh func(factory *core.BeanFactory, bean any) error
+	// ErrorHandler handle the errors of the polling, such as the syntax errors, nil ignores them
+	ErrorHandler func(err error)
+
+	factory  *core.BeanFactory
+	dir      string
+	lock     sync.Mutex           // guards the fields below
+	states   map[string]fileState // path -> state
+	names    []string             // the names of the loaded sources
+	stop     chan struct{}
+	started  bool
+	stopOnce sync.Once
+}
+
+// NewWatcher create the watcher of the config files in the dir, the files at present are supposed to be loaded,
+// such as by AddDir. 创建配置文件监视器
+func NewWatcher(factory *core.BeanFactory, dir string) *Watcher {
+	watcher := &Watcher{
+		Interval: 5 * time.Second,
+		factory:  factory,
+		dir:      dir,
+		stop:     make(chan struct{}),
+	}
+	watcher.names = ConfigFiles(dir, factory.ActiveProfiles())
+	watcher.states = fileStates(watcher.names)
+	return watcher
+}
+
+// Start poll the files in a background goroutine until Stop is called or the RootContext of the factory is done, it is
+// not a main goroutine of the factory, so it does not keep RunAndWait waiting
+func (_this *Watcher) Start() {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	if _this.started {
+		return
+	}
+	_this.started = true
+	interval := _this.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ctx := _this.factory.RootContext()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-_this.stop:
+				return
+			case <-ticker.C:
+				if _, err := _this.Check(); err != nil && _this.ErrorHandler != nil {
+					_this.ErrorHandler(err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stop the polling
+func (_this *Watcher) Stop() {
+	_this.stopOnce.Do(func() {
+		close(_this.stop)
+	})
+}
+
+// Check reload the files if any of them is modified, added or removed, and publish the event if any effective value
+// changes. nil is returned if nothing changes. the files with errors are checked again after they are modified.
+// 检查并重新加载配置文件
+func (_this *Watcher) Check() (*ConfigChangedEvent, error) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	files := ConfigFiles(_this.dir, _this.factory.ActiveProfiles())
+	states := fileStates(files)
+	changedFiles := diffStates(_this.states, states)
+	if len(changedFiles) == 0 {
+		return nil, nil
+	}
+	_this.states = states
+	sources, err := LoadDir(_this.dir, _this.factory.ActiveProfiles())
+	if err != nil {
+		return nil, err
+	}
+
+	env := _this.factory.Environment()
+	keys := make(map[string]bool)
+	for _, key := range env.Keys() { // the placeholders of any key may refer to the keys of the files
+		keys[key] = true
+	}
+	for _, name := range _this.names {
+		if source, ok := env.GetPropertySource(name); ok {
+			for _, key := range source.Keys() {
+				keys[key] = true
+			}
+		}
+	}
+	for _, source := range sources {
+		for _, key := range source.Keys() {
+			keys[key] = true
+		}
+	}
+	before := snapshot(env, keys)
+	env.ReplaceSources(_this.names, sources)
+	_this.names = make([]string, 0, len(sources))
+	for _, source := range sources {
+		_this.names = append(_this.names, source.Name())
+	}
+	after := snapshot(env, keys)
+
+	var changedKeys []string
+	for key := range keys {
+		if before[key] != after[key] {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	if len(changedKeys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(changedKeys)
+	event := &ConfigChangedEvent{Keys: changedKeys, Files: changedFiles}
+	return event, _this.publish(event)
+}
+
+// publish refresh the beans, then notify the listeners. the first error is returned after all beans are refreshed.
+func (_this *Watcher) publish(event *ConfigChangedEvent) (err error) {
+	beans := _this.beans()
+	if _this.Refresh != nil {
+		for _, bean := range beans {
+			if refreshErr := _this.Refresh(_this.factory, bean.value); refreshErr != nil && err == nil {
+				err = fmt.Errorf("failed to refresh the bean '%s': %w", bean.name, refreshErr)
+			}
+		}
+	}
+	for _, bean := range beans {
+		if listener, ok := bean.value.(ConfigChangedListener); ok {
+			listener.OnConfigChanged(event)
+		}
+	}
+	return err
+}
+
+// namedBean the bean with its name
+type namedBean struct {
+	name  string
+	value any
+}
+
+// beans get the beans of the factory sorted by name, a bean added by several names is returned once
+func (_this *Watcher) beans() []namedBean {
+	names := _this.factory.BeanContainer.GetAllBeanNames()
+	sort.Strings(names)
+	seen := make(map[uintptr]bool)
+	var beans []namedBean
+	for _, name := range names {
+		bean, err := _this.factory.GetBean(name)
+		if err != nil || bean == nil {
+			continue
+		}
+		if value := reflect.ValueOf(bean); value.Kind() == reflect.Ptr {
+			if seen[value.Pointer()] {
+				continue
+			}
+			seen[value.Pointer()] = true
+		}
+		beans = append(beans, namedBean{name: name, value: bean})
+	}
+	return beans
+}
+
+// propertyValue the value of a property, ok is false if it is not found
+type propertyValue struct {
+	value string
+	ok    bool
+}
+
+// snapshot get the resolved values of the keys, so a=${b} changes with b. the raw value is kept if it can not be resolved
+func snapshot(env *core.Environment, keys map[string]bool) map[string]propertyValue {
+	values := make(map[string]propertyValue, len(keys))
+	for key := range keys {
+		value, ok := env.GetProperty(key)
+		if resolved, err := env.Resolve(value); ok && err == nil {
+			value = resolved
+		}
+		values[key] = propertyValue{value: value, ok: ok}
+	}
+	return values
+}
+
+// fileStates get the states of the files, the files can not be read are skipped
+func fileStates(files []string) map[string]fileState {
+	states := make(map[string]fileState, len(files))
+	for _, path := range files {
+		if info, err := file_util.NewFile(path).Stat(); err == nil {
+			states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return states
+}
+
+// diffStates get the files modified, added or removed, sorted
+func diffStates(before map[string]fileState, after map[string]fileState) []string {
+	var changed []string
+	for path, state := range after {
+		if old, ok := before[path]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,184 @@
+package config_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/bean_init"
+	"github.com/cutexingluo/go-spring/core/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchedBean struct {
+	sync.Mutex
+	Port   int    `value:"${server.port}" refresh:"true"`
+	Name   string `value:"${app.name}"`
+	events []*config.ConfigChangedEvent
+}
+
+func (b *watchedBean) OnConfigChanged(event *config.ConfigChangedEvent) {
+	b.events = append(b.events, event)
+}
+
+// newWatchedFactory the factory with the config files of the dir and the bean added by two names, so it is deduplicated
+func newWatchedFactory(t *testing.T, dir string) (*core.BeanFactory, *watchedBean) {
+	t.Helper()
+	factory := core.NewBeanFactory()
+	if err := config.AddDir(factory, dir); err != nil {
+		t.Fatal(err)
+	}
+	bean := &watchedBean{Port: 80, Name: "app"}
+	for _, beanName := range []string{"first", "second"} {
+		if _, err := factory.AddMultiBean(beanName, bean); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return factory, bean
+}
+
+// rewrite write the file and move its modification time forward, so the change is seen at once
+func rewrite(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.properties": "server.port=80\napp.name=app\nalias=${server.port}\n"})
+	path := filepath.Join(dir, "application.properties")
+	factory, bean := newWatchedFactory(t, dir)
+	watcher := config.NewWatcher(factory, dir)
+	watcher.Refresh = bean_init.RefreshWith
+
+	if event, err := watcher.Check(); event != nil || err != nil {
+		t.Fatalf("got the event %+v and the error %v before any change", event, err)
+	}
+
+	rewrite(t, path, "server.port=8080\napp.name=changed\nalias=${server.port}\nadded=1\n")
+	event, err := watcher.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.ConfigChangedEvent{Keys: []string{"added", "alias", "app.name", "server.port"}, Files: []string{path}}
+	if !reflect.DeepEqual(event, want) {
+		t.Errorf("got the event %+v, want %+v", event, want)
+	}
+	if bean.Port != 8080 {
+		t.Errorf("the refresh field is %d, want 8080", bean.Port)
+	}
+	if bean.Name != "app" {
+		t.Errorf("the field without refresh is changed to %q", bean.Name)
+	}
+	if len(bean.events) != 1 || !reflect.DeepEqual(bean.events[0], want) {
+		t.Errorf("the listener got %d events, want the event once: %+v", len(bean.events), bean.events)
+	}
+
+	rewrite(t, path, "server.port=8080\napp.name=changed\nalias=${server.port}\nadded=1\n# only a comment\n")
+	if event, err = watcher.Check(); event != nil || err != nil {
+		t.Errorf("got the event %+v and the error %v when no value changes", event, err)
+	}
+
+	rewrite(t, path, "invalid\n")
+	if _, err = watcher.Check(); err == nil {
+		t.Error("no error for the invalid file")
+	}
+	if value, _ := factory.Environment().GetProperty("server.port"); value != "8080" {
+		t.Errorf("the invalid file replaced the config, server.port is %q", value)
+	}
+
+	if err = os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	// the event is still published, but the refresh field can not be resolved and is unchanged
+	if event, err = watcher.Check(); err == nil || event == nil || len(event.Keys) != 4 {
+		t.Errorf("got the event %+v and the error %v after the file is removed, want all keys and the error", event, err)
+	}
+	if _, ok := factory.Environment().GetProperty("server.port"); ok {
+		t.Error("the properties of the removed file are kept")
+	}
+	if bean.Port != 8080 || len(bean.events) != 2 {
+		t.Errorf("got the port %d and %d events, want 8080 and 2 events", bean.Port, len(bean.events))
+	}
+}
+
+// TestWatcherResolvedValue a=${b} in one file changes when b changes in another file
+func TestWatcherResolvedValue(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.properties": "a=${b}\n", "application.yaml": "b: 1\n"})
+	factory := core.NewBeanFactory()
+	if err := config.AddDir(factory, dir); err != nil {
+		t.Fatal(err)
+	}
+	watcher := config.NewWatcher(factory, dir)
+	rewrite(t, filepath.Join(dir, "application.yaml"), "b: 2\n")
+	event, err := watcher.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event == nil || !reflect.DeepEqual(event.Keys, []string{"a", "b"}) {
+		t.Errorf("got the event %+v, want the keys a and b", event)
+	}
+}
+
+func TestWatcherRefreshError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.properties": "server.port=80\n"})
+	factory, _ := newWatchedFactory(t, dir)
+	watcher := config.NewWatcher(factory, dir)
+	refreshErr := errors.New("refresh failed")
+	watcher.Refresh = func(factory *core.BeanFactory, bean any) error {
+		return refreshErr
+	}
+	rewrite(t, filepath.Join(dir, "application.properties"), "server.port=81\n")
+	if _, err := watcher.Check(); !errors.Is(err, refreshErr) {
+		t.Errorf("got the error %v, want the refresh error", err)
+	}
+}
+
+func TestWatcherStartStop(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"application.properties": "server.port=80\n"})
+	path := filepath.Join(dir, "application.properties")
+	factory := core.NewBeanFactory()
+	if err := config.AddDir(factory, dir); err != nil {
+		t.Fatal(err)
+	}
+	watcher := config.NewWatcher(factory, dir)
+	watcher.Interval = 5 * time.Millisecond
+	errs := make(chan error, 10)
+	watcher.ErrorHandler = func(err error) {
+		errs <- err
+	}
+	watcher.Start()
+	watcher.Start() // started once
+
+	rewrite(t, path, "invalid\n")
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the polling did not report the invalid file")
+	}
+	select {
+	case <-factory.MainDone():
+	default:
+		t.Error("the watcher is a main goroutine of the factory")
+	}
+
+	watcher.Stop()
+	watcher.Stop() // stopped once
+	time.Sleep(20 * time.Millisecond)
+	for len(errs) > 0 {
+		<-errs
+	}
+	rewrite(t, path, "still invalid\n")
+	time.Sleep(50 * time.Millisecond)
+	if len(errs) > 0 {
+		t.Errorf("the watcher polls after Stop: %v", <-errs)
+	}
+}
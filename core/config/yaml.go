@@ -0,0 +1,226 @@
+package config
+
+import (
+	"strings"
+)
+
+// yamlLine a line of the yaml document without the blank and comment lines
+type yamlLine struct {
+	number int    // the line number, from 1
+	indent int    // the count of the leading spaces
+	text   string // the text without the indent
+}
+
+// yamlParser parse the block structure of the yaml document by the indents
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// LoadYAML load the yaml subset: the block mappings and sequences, the plain, quoted and block scalars (| and >), and
+// the flow collections [a, b] and {a: 1}. the anchors, tags and multiple documents are not supported, and the blank and
+// comment lines in the block scalars are ignored. the nested keys are flattened. 加载 yaml 文件
+func LoadYAML(data []byte) (map[string]string, error) {
+	parser := &yamlParser{}
+	for index, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text[0] == '#' || text == "..." {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, syntaxError("yaml", index+1, "tabs are not allowed in the indent")
+		}
+		if text == "---" {
+			if len(parser.lines) > 0 {
+				return nil, syntaxError("yaml", index+1, "multiple documents are not supported")
+			}
+			continue
+		}
+		parser.lines = append(parser.lines, yamlLine{number: index + 1, indent: len(raw) - len(text), text: text})
+	}
+	properties := make(map[string]string)
+	if len(parser.lines) == 0 {
+		return properties, nil
+	}
+	tree, err := parser.parseNode(parser.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.lines) {
+		return nil, syntaxError("yaml", parser.lines[parser.pos].number, "unexpected indent")
+	}
+	if _, ok := tree.(map[string]any); !ok {
+		return nil, syntaxError("yaml", parser.lines[0].number, "the document must be a mapping")
+	}
+	Flatten("", tree, properties)
+	return properties, nil
+}
+
+// parseNode parse the sequence or the mapping at the indent
+func (_this *yamlParser) parseNode(indent int) (any, error) {
+	if isSequenceItem(_this.lines[_this.pos].text) {
+		return _this.parseSequence(indent)
+	}
+	return _this.parseMapping(indent)
+}
+
+// parseSequence parse the items like "- value" at the indent
+func (_this *yamlParser) parseSequence(indent int) (any, error) {
+	items := make([]any, 0)
+	for _this.pos < len(_this.lines) && _this.lines[_this.pos].indent == indent && isSequenceItem(_this.lines[_this.pos].text) {
+		line := _this.lines[_this.pos]
+		rest := strings.TrimLeft(line.text[1:], " ")
+		var item any
+		var err error
+		if rest == "" { // the item is on the next lines
+			_this.pos++
+			if _this.pos < len(_this.lines) && _this.lines[_this.pos].indent > indent {
+				item, err = _this.parseNode(_this.lines[_this.pos].indent)
+			}
+		} else if isSequenceItem(rest) || isMappingEntry(rest) { // "- key: value" or "- - value", the node starts after "- "
+			_this.lines[_this.pos] = yamlLine{number: line.number, indent: indent + len(line.text) - len(rest), text: rest}
+			item, err = _this.parseNode(_this.lines[_this.pos].indent)
+		} else {
+			item, err = parseYAMLScalar(rest, line.number)
+			_this.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseMapping parse the entries like "key: value" at the indent
+func (_this *yamlParser) parseMapping(indent int) (any, error) {
+	m := make(map[string]any)
+	for _this.pos < len(_this.lines) && _this.lines[_this.pos].indent == indent {
+		line := _this.lines[_this.pos]
+		if isSequenceItem(line.text) {
+			return nil, syntaxError("yaml", line.number, "unexpected sequence item in the mapping")
+		}
+		key, value, ok := splitYAMLEntry(line.text)
+		if !ok {
+			return nil, syntaxError("yaml", line.number, "expected 'key: value', got '%s'", line.text)
+		}
+		if _, exists := m[key]; exists {
+			return nil, syntaxError("yaml", line.number, "duplicate key '%s'", key)
+		}
+		_this.pos++
+		var child any
+		var err error
+		switch {
+		case value == "":
+			if _this.pos < len(_this.lines) {
+				next := _this.lines[_this.pos]
+				if next.indent > indent {
+					child, err = _this.parseNode(next.indent)
+				} else if next.indent == indent && isSequenceItem(next.text) { // the sequence can be at the same indent
+					child, err = _this.parseSequence(indent)
+				}
+			}
+		case value[0] == '|' || value[0] == '>':
+			child, err = _this.parseBlockScalar(indent, value, line.number)
+		default:
+			child, err = parseYAMLScalar(value, line.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = child
+	}
+	if _this.pos < len(_this.lines) && _this.lines[_this.pos].indent > indent {
+		return nil, syntaxError("yaml", _this.lines[_this.pos].number, "unexpected indent")
+	}
+	return m, nil
+}
+
+// parseBlockScalar parse the literal (|) or folded (>) block scalar, the lines are more indented than the key.
+// the chomping indicator - removes the final line break.
+func (_this *yamlParser) parseBlockScalar(indent int, header string, number int) (any, error) {
+	indicators := stripComment(header[1:])
+	if strings.Trim(indicators, "+-0123456789") != "" {
+		return nil, syntaxError("yaml", number, "invalid block scalar header '%s'", header)
+	}
+	var texts []string
+	blockIndent := -1
+	for _this.pos < len(_this.lines) && _this.lines[_this.pos].indent > indent {
+		line := _this.lines[_this.pos]
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			return nil, syntaxError("yaml", line.number, "the line is less indented than the block scalar")
+		}
+		texts = append(texts, strings.Repeat(" ", line.indent-blockIndent)+line.text)
+		_this.pos++
+	}
+	separator := "\n"
+	if header[0] == '>' {
+		separator = " "
+	}
+	value := strings.Join(texts, separator)
+	if len(texts) > 0 && !strings.Contains(indicators, "-") {
+		value += "\n"
+	}
+	return value, nil
+}
+
+// parseYAMLScalar parse the value after "key:" or "- ", such as a plain scalar, a quoted string or a flow collection
+func parseYAMLScalar(text string, number int) (any, error) {
+	switch text[0] {
+	case '"', '\'', '[', '{':
+		parser := newFlowParser("yaml", number, text, ':')
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return value, parser.end()
+	}
+	return newFlowParser("yaml", number, "", ':').plainValue(stripComment(text)), nil
+}
+
+// splitYAMLEntry split "key: value", the key can be quoted
+func splitYAMLEntry(text string) (key string, value string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		parser := newFlowParser("yaml", 0, text, ':')
+		quoted, err := parser.parseQuoted()
+		if err != nil {
+			return "", "", false
+		}
+		rest := text[parser.pos:]
+		if !strings.HasPrefix(rest, ":") || len(rest) > 1 && rest[1] != ' ' {
+			return "", "", false
+		}
+		return quoted.(string), strings.TrimSpace(rest[1:]), true
+	}
+	index := strings.Index(text, ": ")
+	if index < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		index = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:index])
+	if key == "" || strings.HasPrefix(key, "[") || strings.HasPrefix(key, "{") {
+		return "", "", false
+	}
+	value = strings.TrimSpace(text[index+1:])
+	if strings.HasPrefix(value, "#") { // "key: # comment"
+		value = ""
+	}
+	return key, value, true
+}
+
+// isSequenceItem checks whether the text is a sequence item like "- value"
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isMappingEntry checks whether the text is a mapping entry like "key: value"
+func isMappingEntry(text string) bool {
+	_, _, ok := splitYAMLEntry(text)
+	return ok
+}
@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"github.com/cutexingluo/go-spring/core/config"
+	"testing"
+)
+
+func TestLoadYAML(t *testing.T) {
+	runLoaderTests(t, config.LoadYAML, []loaderTest{
+		{name: "empty", data: "# comment\n\n", want: map[string]string{}},
+		{name: "mapping", data: "---\nserver:\n  port: 8080 # comment\n  host: local\nname: app\n...\n",
+			want: map[string]string{"server.port": "8080", "server.host": "local", "name": "app"}},
+		{name: "sequence", data: "hosts:\n  - a\n  - b\nports:\n- 1\n- 2\n",
+			want: map[string]string{"hosts[0]": "a", "hosts[1]": "b", "ports[0]": "1", "ports[1]": "2"}},
+		{name: "sequence of mappings", data: "servers:\n  - host: a\n    port: 1\n  -\n    host: b\n",
+			want: map[string]string{"servers[0].host": "a", "servers[0].port": "1", "servers[1].host": "b"}},
+		{name: "nested sequence", data: "matrix:\n  - - 1\n    - 2\n  - - 3\n",
+			want: map[string]string{"matrix[0][0]": "1", "matrix[0][1]": "2", "matrix[1][0]": "3"}},
+		{name: "quotes and escapes", data: "a: \"x\\ty # not a comment\"\nb: 'it''s'\n\"c.d\": e\n'f': \"\\u00e9\"\n",
+			want: map[string]string{"a": "x\ty # not a comment", "b": "it's", "c.d": "e", "f": "é"}},
+		{name: "null and empty", data: "a: ~\nb: null\nc:\nd: # comment\n",
+			want: map[string]string{"a": "", "b": "", "c": "", "d": ""}},
+		{name: "flow collections", data: "list: [a, 'b, c', [1, 2], ]\nmap: {x: 1, \"y z\": {w: 2}}\nempty: []\n",
+			want: map[string]string{"list[0]": "a", "list[1]": "b, c", "list[2][0]": "1", "list[2][1]": "2",
+				"map.x": "1", "map.y z.w": "2", "empty": ""}},
+		{name: "literal block", data: "text: |\n  line 1\n    line 2\nnext: x\n",
+			want: map[string]string{"text": "line 1\n  line 2\n", "next": "x"}},
+		{name: "folded block strip", data: "text: >-\n  a\n  b\n",
+			want: map[string]string{"text": "a b"}},
+		{name: "colon in value", data: "url: http://host:80/a\n",
+			want: map[string]string{"url": "http://host:80/a"}},
+		{name: "tab indent", data: "a:\n\tb: 1\n", errorLine: 2},
+		{name: "multiple documents", data: "a: 1\n---\nb: 2\n", errorLine: 2},
+		{name: "unexpected indent", data: "a: 1\n  b: 2\n", errorLine: 2},
+		{name: "less indent", data: "a:\n    b: 1\n  c: 2\n", errorLine: 3},
+		{name: "not a mapping", data: "- a\n", errorLine: 1},
+		{name: "sequence in mapping", data: "a: 1\n- b\n", errorLine: 2},
+		{name: "no colon", data: "a: 1\njust text\n", errorLine: 2},
+		{name: "duplicate key", data: "a: 1\nb: 2\na: 3\n", errorLine: 3},
+		{name: "block header", data: "a: |x\n  b\n", errorLine: 1},
+		{name: "block less indented", data: "a: |\n    b\n  c\n", errorLine: 3},
+		{name: "unclosed string", data: "a: 1\nb: \"x\n", errorLine: 2},
+		{name: "unclosed list", data: "a: [1, 2\n", errorLine: 1},
+		{name: "flow separator", data: "a: [1 2] x\n", errorLine: 1},
+		{name: "after value", data: "a: \"x\" y\n", errorLine: 1},
+		{name: "flow duplicate key", data: "a: {b: 1, b: 2}\n", errorLine: 1},
+		{name: "flow map key", data: "a: {b}\n", errorLine: 1},
+	})
+}
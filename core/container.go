@@ -38,12 +38,21 @@ type Container interface {
 	RemoveMultiBean(beanName string, beanType string) (bool, error) // RemoveMultiBean remove a multi bean by beanName and beanType, if nil do nothing return false,nil
 }
 
+// FreezableContainer the container can be frozen, it is read-only after Freeze, the mutations return ErrContainerFrozen.
+// the BeanFactory freezes it after BeanInjected. the frozen flag of BeanContainer is a plain bool, so Freeze is only safe
+// with the concurrent reads behind ConcurrentBeanContainer, which uses an atomic flag. 可冻结的容器, 冻结后只读
+type FreezableContainer interface {
+	Freeze()        // Freeze turns the container read-only
+	IsFrozen() bool // IsFrozen checks whether the container is frozen
+}
+
 type BeanContainer struct {
 	singleBeans    map[string]*reflect.Value // beanType -> bean , single
 	multiBeans     map[string]*reflect.Value // beanName -> bean , multi
 	types          map[string]string         // beanName -> beanType , single and multi
 	beanCollection map[string][]string       // beanType -> beanNames , multi
 	typeSet        map[string]int            // beanType 's  Set -> single is 1, multi is 2
+	frozen         bool                      // read-only after Freeze
 }
 
 // NewBeanContainer creates a new Container
@@ -57,6 +66,16 @@ func NewBeanContainer() *BeanContainer {
 	}
 }
 
+// Freeze turns the container read-only
+func (_this *BeanContainer) Freeze() {
+	_this.frozen = true
+}
+
+// IsFrozen checks whether the container is frozen
+func (_this *BeanContainer) IsFrozen() bool {
+	return _this.frozen
+}
+
 // HasBean checks whether the beanName in the container
 func (_this *BeanContainer) HasBean(beanNameOrType string) bool {
 	beanNameOrType = BeanNameFilter(beanNameOrType)
@@ -120,6 +139,9 @@ func (_this *BeanContainer) addSingleBean(beanType string, bean *reflect.Value)
 
 // AddSingleBean add a single bean to the collection
 func (_this *BeanContainer) AddSingleBean(bean *reflect.Value) (isAdd bool, err error) {
+	if _this.frozen {
+		return false, &ErrContainerFrozen{Operation: "AddSingleBean", BeanName: BeanNameFilter(bean.Type().String())}
+	}
 	beanType := bean.Type().String()
 	beanType = BeanNameFilter(beanType)                                 // 统一类型
 	if _this.hasBeanType(beanType) || _this.checkSingleBean(beanType) { // 已经存在
@@ -154,6 +176,9 @@ func (_this *BeanContainer) AddMultiBean(beanName string, bean *reflect.Value) (
 	if beanName == "" {
 		return false, nil
 	}
+	if _this.frozen {
+		return false, &ErrContainerFrozen{Operation: "AddMultiBean", BeanName: beanName}
+	}
 	beanType := bean.Type().String()
 	beanType = BeanNameFilter(beanType)                                     // 统一类型
 	if _this.checkSingleBean(beanType) || _this.checkSingleBean(beanName) { // 已经存在
@@ -181,6 +206,9 @@ func (_this *BeanContainer) UpdateSingleBean(beanType string, bean *reflect.Valu
 		return false, fmt.Errorf("this bean '%s' is not a single bean , because it has multiBeans ", beanType)
 	}
 	if _this.checkSingleBean(beanType) { // 如果存在
+		if _this.frozen {
+			return false, &ErrContainerFrozen{Operation: "UpdateSingleBean", BeanName: beanType}
+		}
 		_this.updateSingleBean(beanType, bean)
 		return true, nil
 	} else {
@@ -198,6 +226,9 @@ func (_this *BeanContainer) UpdateSingleBeanFilter(beanType string, filter func(
 		return false, fmt.Errorf("this bean '%s' is not a single bean , because it has multiBeans ", beanType)
 	}
 	if _this.checkSingleBean(beanType) { // 如果存在
+		if _this.frozen { // the filter may change the bean itself, so it is not called
+			return false, &ErrContainerFrozen{Operation: "UpdateSingleBeanFilter", BeanName: beanType}
+		}
 		bean, err := filter(_this.singleBeans[beanType])
 		if err != nil {
 			return false, err
@@ -227,6 +258,9 @@ func (_this *BeanContainer) UpdateMultiBean(beanName string, beanType string, be
 	if _this.checkSingleBean(beanType) || _this.checkSingleBean(beanName) { // 如果是单实例，则更改失败
 		return false, fmt.Errorf("this bean 'type: '%s' , bean: '%s' ' is not a multi bean, because it has a singleBean ", beanType, beanName)
 	} else if _this.checkMultiType(beanType) { // 如果存在
+		if _this.frozen {
+			return false, &ErrContainerFrozen{Operation: "UpdateMultiBean", BeanName: beanName}
+		}
 		_this.updateMultiBean(beanName, bean)
 		return true, nil
 	} else {
@@ -244,6 +278,9 @@ func (_this *BeanContainer) UpdateMultiBeanFilter(beanName string, beanType stri
 	if _this.checkSingleBean(beanType) || _this.checkSingleBean(beanName) { // 如果是单实例 则更改失败
 		return false, fmt.Errorf("this bean 'type: '%s' , bean: '%s' ' is not a multi bean, because it has a singleBean ", beanType, beanName)
 	} else if _this.checkMultiBean(beanName) { // 如果存在
+		if _this.frozen { // the filter may change the bean itself, so it is not called
+			return false, &ErrContainerFrozen{Operation: "UpdateMultiBeanFilter", BeanName: beanName}
+		}
 		bean, err := filter(_this.multiBeans[beanName])
 		if err != nil {
 			return false, err
@@ -265,6 +302,9 @@ func (_this *BeanContainer) UpdateBeanFilter(beanName string, beanType string, f
 		return false, nil
 	}
 	if _this.checkSingleBean(beanType) || _this.checkSingleBean(beanName) { // 如果是单实例
+		if _this.frozen { // the filter may change the bean itself, so it is not called
+			return false, &ErrContainerFrozen{Operation: "UpdateBeanFilter", BeanName: beanType}
+		}
 		bean, err := filter(true, _this.singleBeans[beanType])
 		if err != nil {
 			return false, err
@@ -274,6 +314,9 @@ func (_this *BeanContainer) UpdateBeanFilter(beanName string, beanType string, f
 		}
 		return true, nil
 	} else if _this.checkMultiBean(beanName) { // 如果存在多例
+		if _this.frozen { // the filter may change the bean itself, so it is not called
+			return false, &ErrContainerFrozen{Operation: "UpdateBeanFilter", BeanName: beanName}
+		}
 		bean, err := filter(false, _this.multiBeans[beanName])
 		if err != nil {
 			return false, err
@@ -358,6 +401,7 @@ func (_this *BeanContainer) GetBean(beanNameOrType string) (*reflect.Value, erro
 func (_this *BeanContainer) removeSingleBean(beanType string) {
 	delete(_this.singleBeans, beanType)
 	delete(_this.types, beanType)
+	delete(_this.typeSet, beanType)
 }
 
 // RemoveSingleBean remove a single bean by beanType, if nil do nothing return false,nil
@@ -367,6 +411,9 @@ func (_this *BeanContainer) RemoveSingleBean(beanType string) (bool, error) {
 		return false, nil
 	}
 	if _this.checkSingleBean(beanType) {
+		if _this.frozen {
+			return false, &ErrContainerFrozen{Operation: "RemoveSingleBean", BeanName: beanType}
+		}
 		_this.removeSingleBean(beanType)
 		return true, nil
 	} else if _this.checkMultiBean(beanType) {
@@ -380,8 +427,13 @@ func (_this *BeanContainer) RemoveSingleBean(beanType string) (bool, error) {
 func (_this *BeanContainer) removeMultiBean(beanName string, beanType string) {
 	delete(_this.multiBeans, beanName)
 	delete(_this.types, beanName)
-	beans := _this.beanCollection[beanType]
-	slice_util.RemoveFirstString(beans, beanName) // 效率低
+	beans := slice_util.RemoveFirstString(_this.beanCollection[beanType], beanName) // 效率低
+	if len(beans) == 0 {
+		delete(_this.beanCollection, beanType)
+		delete(_this.typeSet, beanType)
+	} else {
+		_this.beanCollection[beanType] = beans
+	}
 }
 
 // RemoveMultiBean remove a multi bean by beanName and beanType, if nil do nothing return false,nil
@@ -393,6 +445,9 @@ func (_this *BeanContainer) RemoveMultiBean(beanName string, beanType string) (b
 		return false, nil
 	}
 	if _this.checkMultiBean(beanName) && _this.checkMultiType(beanType) {
+		if _this.frozen {
+			return false, &ErrContainerFrozen{Operation: "RemoveMultiBean", BeanName: beanName}
+		}
 		_this.removeMultiBean(beanName, beanType)
 		return true, nil
 	} else if _this.IsSingleBean(beanType) || _this.checkSingleBean(beanName) {
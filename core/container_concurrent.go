@@ -0,0 +1,242 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentBeanContainer the BeanContainer guarded by a RWMutex, it is safe for concurrent use, such as getting beans
+// from the request goroutines or the useGo goroutines. the writers are serialized by another mutex, which is held for
+// the whole read-modify-write of the Update*Filter methods, so no update is lost. the filters are executed without the
+// RWMutex, so they can get other beans from the container, but they must not write to it. after Freeze, the reads are
+// lock-free. 并发安全的容器
+type ConcurrentBeanContainer struct {
+	mu        sync.RWMutex
+	writeMu   sync.Mutex  // serializes the writers, held across the filters of the Update*Filter methods
+	frozen    atomic.Bool // no writes after it is true, so the reads skip the lock
+	container *BeanContainer
+}
+
+// NewConcurrentBeanContainer creates a new ConcurrentBeanContainer
+func NewConcurrentBeanContainer() *ConcurrentBeanContainer {
+	return &ConcurrentBeanContainer{
+		container: NewBeanContainer(),
+	}
+}
+
+// Freeze turns the container read-only, the mutations return ErrContainerFrozen
+func (_this *ConcurrentBeanContainer) Freeze() {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.container.Freeze()
+	_this.frozen.Store(true)
+}
+
+// IsFrozen checks whether the container is frozen
+func (_this *ConcurrentBeanContainer) IsFrozen() bool {
+	return _this.frozen.Load()
+}
+
+// readLock lock for reading if the container is not frozen, returns whether it is locked
+func (_this *ConcurrentBeanContainer) readLock() bool {
+	if _this.frozen.Load() {
+		return false
+	}
+	_this.mu.RLock()
+	return true
+}
+
+// readUnlock unlock if it is locked by readLock
+func (_this *ConcurrentBeanContainer) readUnlock(locked bool) {
+	if locked {
+		_this.mu.RUnlock()
+	}
+}
+
+// HasBean checks whether the beanName in the container
+func (_this *ConcurrentBeanContainer) HasBean(beanNameOrType string) bool {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.HasBean(beanNameOrType)
+}
+
+// HasBeanType checks whether the beanType in the container
+func (_this *ConcurrentBeanContainer) HasBeanType(beanType string) bool {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.HasBeanType(beanType)
+}
+
+// IsSingleBean checks whether the bean is single
+func (_this *ConcurrentBeanContainer) IsSingleBean(beanNameOrType string) bool {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.IsSingleBean(beanNameOrType)
+}
+
+// IsMultiBean checks whether the bean is multiple
+func (_this *ConcurrentBeanContainer) IsMultiBean(beanName string) bool {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.IsMultiBean(beanName)
+}
+
+//------------- add--------------
+
+// AddSingleBean add a single bean to the collection
+func (_this *ConcurrentBeanContainer) AddSingleBean(bean *reflect.Value) (isAdd bool, err error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.AddSingleBean(bean)
+}
+
+// AddMultiBean add a multi bean to the collection
+func (_this *ConcurrentBeanContainer) AddMultiBean(beanName string, bean *reflect.Value) (isAdd bool, err error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.AddMultiBean(beanName, bean)
+}
+
+// ------------- update--------------
+
+// UpdateSingleBean update the single bean to the collection
+func (_this *ConcurrentBeanContainer) UpdateSingleBean(beanType string, bean *reflect.Value) (bool, error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.UpdateSingleBean(beanType, bean)
+}
+
+// UpdateSingleBeanFilter update the single bean to the collection by filter, the filter must not write to the container
+func (_this *ConcurrentBeanContainer) UpdateSingleBeanFilter(beanType string, filter func(bean *reflect.Value) (ret *reflect.Value, err error)) (bool, error) {
+	return _this.updateFilter(
+		func(read func(bean *reflect.Value) (*reflect.Value, error)) (bool, error) {
+			return _this.container.UpdateSingleBeanFilter(beanType, read)
+		}, filter)
+}
+
+// UpdateMultiBean update a multi bean to the collection
+func (_this *ConcurrentBeanContainer) UpdateMultiBean(beanName string, beanType string, bean *reflect.Value) (bool, error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.UpdateMultiBean(beanName, beanType, bean)
+}
+
+// UpdateMultiBeanFilter update a multi bean to the collection by filter, the filter must not write to the container
+func (_this *ConcurrentBeanContainer) UpdateMultiBeanFilter(beanName string, beanType string, filter func(bean *reflect.Value) (ret *reflect.Value, err error)) (bool, error) {
+	return _this.updateFilter(
+		func(read func(bean *reflect.Value) (*reflect.Value, error)) (bool, error) {
+			return _this.container.UpdateMultiBeanFilter(beanName, beanType, read)
+		}, filter)
+}
+
+// UpdateBeanFilter update a bean to the collection by filter, the filter must not write to the container
+func (_this *ConcurrentBeanContainer) UpdateBeanFilter(beanName string, beanType string, filter func(isSingle bool, bean *reflect.Value) (ret *reflect.Value, err error)) (bool, error) {
+	var isSingle bool
+	return _this.updateFilter(
+		func(read func(bean *reflect.Value) (*reflect.Value, error)) (bool, error) {
+			return _this.container.UpdateBeanFilter(beanName, beanType, func(single bool, bean *reflect.Value) (*reflect.Value, error) {
+				isSingle = single
+				return read(bean)
+			})
+		},
+		func(bean *reflect.Value) (*reflect.Value, error) {
+			return filter(isSingle, bean)
+		})
+}
+
+// updateFilter hold the writer lock for the whole read-modify-write: read the bean by update under the read lock,
+// execute the filter without the RWMutex, then write the result by update under the write lock. update must only call
+// its argument as the filter.
+func (_this *ConcurrentBeanContainer) updateFilter(
+	update func(filter func(bean *reflect.Value) (*reflect.Value, error)) (bool, error),
+	filter func(bean *reflect.Value) (ret *reflect.Value, err error)) (bool, error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	var current *reflect.Value
+	locked := _this.readLock()
+	found, err := update(func(bean *reflect.Value) (*reflect.Value, error) {
+		current = bean
+		return nil, nil // unchanged
+	})
+	_this.readUnlock(locked)
+	if err != nil || !found {
+		return found, err
+	}
+	ret, err := filter(current)
+	if err != nil {
+		return false, err
+	}
+	if ret == nil {
+		return true, nil
+	}
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return update(func(bean *reflect.Value) (*reflect.Value, error) {
+		return ret, nil
+	})
+}
+
+//------------- get--------------
+
+// GetAllBeanNames get a snapshot of all bean names
+func (_this *ConcurrentBeanContainer) GetAllBeanNames() []string {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.GetAllBeanNames()
+}
+
+// GetSingleBean get a single bean by beanType
+func (_this *ConcurrentBeanContainer) GetSingleBean(beanType string) *reflect.Value {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.GetSingleBean(beanType)
+}
+
+// GetMultiBean get a multi bean by beanName
+func (_this *ConcurrentBeanContainer) GetMultiBean(beanName string) *reflect.Value {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.GetMultiBean(beanName)
+}
+
+// GetBean get a bean by beanName or beanType
+func (_this *ConcurrentBeanContainer) GetBean(beanNameOrType string) (*reflect.Value, error) {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.GetBean(beanNameOrType)
+}
+
+// GetMultiBeanNames get a snapshot of the bean names of the type
+func (_this *ConcurrentBeanContainer) GetMultiBeanNames(beanType string) []string {
+	defer _this.readUnlock(_this.readLock())
+	return append([]string(nil), _this.container.GetMultiBeanNames(beanType)...)
+}
+
+// GetType get bean type, if it not found return ""
+func (_this *ConcurrentBeanContainer) GetType(beanName string) string {
+	defer _this.readUnlock(_this.readLock())
+	return _this.container.GetType(beanName)
+}
+
+// ------------- remove--------------
+
+// RemoveSingleBean remove a single bean by beanType, if nil do nothing return false,nil
+func (_this *ConcurrentBeanContainer) RemoveSingleBean(beanType string) (bool, error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.RemoveSingleBean(beanType)
+}
+
+// RemoveMultiBean remove a multi bean by beanName and beanType, if nil do nothing return false,nil
+func (_this *ConcurrentBeanContainer) RemoveMultiBean(beanName string, beanType string) (bool, error) {
+	_this.writeMu.Lock()
+	defer _this.writeMu.Unlock()
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.container.RemoveMultiBean(beanName, beanType)
+}
@@ -0,0 +1,181 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cutexingluo/go-spring/core"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+type concurrentCounter struct {
+	N int
+}
+
+// TestConcurrentBeanContainerUpdateFilter the concurrent Update*Filter calls must not lose any update
+func TestConcurrentBeanContainerUpdateFilter(t *testing.T) {
+	container := core.NewConcurrentBeanContainer()
+	bean := reflect.ValueOf(&concurrentCounter{})
+	if _, err := container.AddSingleBean(&bean); err != nil {
+		t.Fatal(err)
+	}
+	beanType := core.BeanNameFilter(bean.Type().String())
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := container.UpdateSingleBeanFilter(beanType, func(bean *reflect.Value) (*reflect.Value, error) {
+				// replace the bean instead of changing it, so only the locks keep the update
+				runtime.Gosched()
+				next := reflect.ValueOf(&concurrentCounter{N: bean.Interface().(*concurrentCounter).N + 1})
+				return &next, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := container.GetSingleBean(beanType).Interface().(*concurrentCounter).N; got != n {
+		t.Errorf("the counter is %d after %d updates, some updates are lost", got, n)
+	}
+}
+
+// TestConcurrentBeanContainerReadWrite run Add, Get, Update and Remove concurrently, it is meant for go test -race
+func TestConcurrentBeanContainerReadWrite(t *testing.T) {
+	container := core.NewConcurrentBeanContainer()
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		beanName := fmt.Sprintf("counter%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bean := reflect.ValueOf(&concurrentCounter{})
+			if _, err := container.AddMultiBean(beanName, &bean); err != nil {
+				t.Error(err)
+				return
+			}
+			beanType := container.GetType(beanName)
+			for j := 0; j < 10; j++ {
+				if _, err := container.GetBean(beanName); err != nil {
+					t.Error(err)
+					return
+				}
+				_, err := container.UpdateBeanFilter(beanName, beanType, func(isSingle bool, bean *reflect.Value) (*reflect.Value, error) {
+					next := reflect.ValueOf(&concurrentCounter{N: bean.Interface().(*concurrentCounter).N + 1})
+					return &next, nil
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				container.GetAllBeanNames()
+				container.GetMultiBeanNames(beanType)
+			}
+			if got := container.GetMultiBean(beanName).Interface().(*concurrentCounter).N; got != 10 {
+				t.Errorf("the bean '%s' is %d after 10 updates", beanName, got)
+			}
+			if _, err := container.RemoveMultiBean(beanName, beanType); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if names := container.GetAllBeanNames(); len(names) != 0 {
+		t.Errorf("the beans %v are not removed", names)
+	}
+}
+
+type concurrentMulti struct{}
+
+type concurrentOther struct{}
+
+// TestConcurrentBeanContainerFreeze the mutations return ErrContainerFrozen after Freeze, and the filters are not called
+func TestConcurrentBeanContainerFreeze(t *testing.T) {
+	// the single bean can not be updated beside the multi beans, so they are in two containers
+	container, multiContainer := core.NewConcurrentBeanContainer(), core.NewConcurrentBeanContainer()
+	single := reflect.ValueOf(&concurrentCounter{})
+	multi := reflect.ValueOf(&concurrentMulti{})
+	if _, err := container.AddSingleBean(&single); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := multiContainer.AddMultiBean("multi", &multi); err != nil {
+		t.Fatal(err)
+	}
+	beanType := core.BeanNameFilter(single.Type().String())
+	multiType := core.BeanNameFilter(multi.Type().String())
+	container.Freeze()
+	multiContainer.Freeze()
+	if !container.IsFrozen() || !multiContainer.IsFrozen() {
+		t.Fatal("the container is not frozen")
+	}
+
+	filterCalled := false
+	filter := func(bean *reflect.Value) (*reflect.Value, error) {
+		filterCalled = true
+		return bean, nil
+	}
+	other := reflect.ValueOf(&concurrentOther{})
+	mutations := map[string]func() (bool, error){
+		"AddSingleBean": func() (bool, error) { return container.AddSingleBean(&other) },
+		"AddMultiBean":  func() (bool, error) { return multiContainer.AddMultiBean("other", &other) },
+		"UpdateSingleBean": func() (bool, error) {
+			return container.UpdateSingleBean(beanType, &single)
+		},
+		"UpdateSingleBeanFilter": func() (bool, error) {
+			return container.UpdateSingleBeanFilter(beanType, filter)
+		},
+		"UpdateMultiBean": func() (bool, error) {
+			return multiContainer.UpdateMultiBean("multi", multiType, &multi)
+		},
+		"UpdateMultiBeanFilter": func() (bool, error) {
+			return multiContainer.UpdateMultiBeanFilter("multi", multiType, filter)
+		},
+		"UpdateBeanFilter": func() (bool, error) {
+			return multiContainer.UpdateBeanFilter("multi", multiType, func(isSingle bool, bean *reflect.Value) (*reflect.Value, error) {
+				return filter(bean)
+			})
+		},
+		"RemoveSingleBean": func() (bool, error) { return container.RemoveSingleBean(beanType) },
+		"RemoveMultiBean":  func() (bool, error) { return multiContainer.RemoveMultiBean("multi", multiType) },
+	}
+	for operation, mutate := range mutations {
+		ok, err := mutate()
+		var frozenErr *core.ErrContainerFrozen
+		if ok || !errors.As(err, &frozenErr) || frozenErr.Operation != operation {
+			t.Errorf("%s got %v and the error %v, want ErrContainerFrozen", operation, ok, err)
+		}
+	}
+	if filterCalled {
+		t.Error("the filter is called after Freeze")
+	}
+	if container.GetSingleBean(beanType) == nil || multiContainer.GetMultiBean("multi") == nil ||
+		multiContainer.HasBean("other") {
+		t.Error("the beans are changed after Freeze")
+	}
+}
+
+// TestFactoryFreeze the settings of the factory are rejected after Freeze
+func TestFactoryFreeze(t *testing.T) {
+	factory := core.NewBeanFactory()
+	factory.Freeze()
+	if !factory.IsFrozen() {
+		t.Fatal("the factory is not frozen")
+	}
+	settings := map[string]error{
+		"SetEnvironment":      factory.SetEnvironment(core.NewEnvironment()),
+		"SetActiveProfiles":   factory.SetActiveProfiles("dev"),
+		"SetPropertyResolver": factory.SetPropertyResolver(nil),
+	}
+	for operation, err := range settings {
+		var frozenErr *core.ErrContainerFrozen
+		if !errors.As(err, &frozenErr) || frozenErr.Operation != operation {
+			t.Errorf("%s got the error %v, want ErrContainerFrozen", operation, err)
+		}
+	}
+}
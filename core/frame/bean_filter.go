@@ -2,26 +2,42 @@ package frame
 
 import (
 	"github.com/cutexingluo/go-spring/core"
+	"github.com/cutexingluo/go-spring/core/bean_init"
+	"reflect"
 )
 
-// AddBeanFilterFunc you can add bean filter function here
+// AddBeanFilterFunc you can add bean filter function here, it is added to core.Context
 func AddBeanFilterFunc(executionTime int, beanFilterFunc *core.BeanFilterFunction) {
-	tagInitialized := core.Context.BeanChains[executionTime]
+	AddFactoryBeanFilterFunc(core.Context, executionTime, beanFilterFunc)
+}
+
+// AddFactoryBeanFilterFunc add the bean filter function to the factory
+func AddFactoryBeanFilterFunc(factory *core.BeanFactory, executionTime int, beanFilterFunc *core.BeanFilterFunction) {
+	tagInitialized := factory.BeanChains[executionTime]
 	tagInitialized = append(tagInitialized, beanFilterFunc)
-	core.Context.BeanChains[executionTime] = tagInitialized
+	factory.BeanChains[executionTime] = tagInitialized
 }
 
-// InitBeanFunc Add BeanFilterFunction InitializeValue and BeanInject
+// InitBeanFunc Add BeanFilterFunction InitializeValue and BeanInject to core.Context
 func InitBeanFunc() {
+	InitFactoryBeanFunc(core.Context)
+}
+
+// InitFactoryBeanFunc Add BeanFilterFunction of InitializeValue and BeanInject to the factory, the tags are parsed with the beans of the factory
+func InitFactoryBeanFunc(factory *core.BeanFactory) {
 	// parse.Initialize
-	AddBeanFilterFunc(core.TagInitialized, &core.BeanFilterFunction{
-		Mode:   core.AllBeanMode,
-		Filter: InitializeValue,
+	AddFactoryBeanFilterFunc(factory, core.TagInitialized, &core.BeanFilterFunction{
+		Mode: core.AllBeanMode,
+		Filter: func(val *reflect.Value) (*reflect.Value, error) {
+			return bean_init.InitializeWith(factory, val)
+		},
 	})
 
 	// parse.BeanInject
-	AddBeanFilterFunc(core.BeanInjected, &core.BeanFilterFunction{
-		Mode:   core.AllBeanMode,
-		Filter: BeanInject,
+	AddFactoryBeanFilterFunc(factory, core.BeanInjected, &core.BeanFilterFunction{
+		Mode: core.AllBeanMode,
+		Filter: func(val *reflect.Value) (*reflect.Value, error) {
+			return bean_init.BeanInjectWith(factory, val)
+		},
 	})
 }
@@ -1,8 +1,9 @@
 package parse
 
 import (
-	"github.com/cutexingluo/go-spring/common/base"
+	"github.com/cutexingluo/go-spring/common/se/slice_util"
 	"github.com/cutexingluo/go-spring/common/structure"
+	"sort"
 )
 
 type DependencyChain struct {
@@ -25,8 +26,9 @@ func NewDependencyNode(chain *DependencyChain, next int) DependencyNode {
 }
 
 type BeanTopo struct {
-	lineMap map[DependencyChain]bool //start
-	total   int                      // line  total
+	lineMap map[DependencyChain]bool     //start
+	reasons map[DependencyChain][]string // the reasons of each line, such as the struct field and tag
+	total   int                          // line  total
 
 	lines []DependencyNode    //from index 1 to total ,inclusive
 	head  map[string]int      // head node index
@@ -47,6 +49,7 @@ func (_this *DependencyChain) Equals(other DependencyChain) bool {
 func NewBeanTopo() *BeanTopo {
 	return &BeanTopo{
 		lineMap: make(map[DependencyChain]bool),
+		reasons: make(map[DependencyChain][]string),
 		in:      make(map[string]int),
 		queue:   structure.NewLinkList(),
 		total:   0,
@@ -55,14 +58,62 @@ func NewBeanTopo() *BeanTopo {
 
 // Add  1. 第一步添加
 func (_this *BeanTopo) Add(src string, to string) {
+	_this.AddWithReason(src, to, "")
+}
+
+// AddWithReason  1. 第一步添加, reason is the cause of the line, such as the struct field and tag, it is used in the errors
+func (_this *BeanTopo) AddWithReason(src string, to string, reason string) {
 	if src == "" || to == "" {
 		return
 	}
-	_this.lineMap[DependencyChain{src: src, to: to}] = true
+	chain := DependencyChain{src: src, to: to}
+	_this.lineMap[chain] = true
+	if reason != "" && !slice_util.ContainsString(_this.reasons[chain], reason) {
+		_this.reasons[chain] = append(_this.reasons[chain], reason)
+	}
 	_this.in[src] = 0 // init node count
 	_this.in[to] = 0
 }
 
+// DependencyEdge the dependency line with its reasons
+type DependencyEdge struct {
+	Src     string   // source bean
+	To      string   // target bean, depended on by the source bean
+	Reasons []string // such as the struct fields and tags
+}
+
+// GetEdges get all dependency lines sorted by src and to
+func (_this *BeanTopo) GetEdges() []DependencyEdge {
+	edges := make([]DependencyEdge, 0, len(_this.lineMap))
+	for line := range _this.lineMap {
+		edges = append(edges, DependencyEdge{Src: line.src, To: line.to, Reasons: _this.reasons[line]})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Src != edges[j].Src {
+			return edges[i].Src < edges[j].Src
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// GetDependencies get the beans depended on by the src bean, sorted
+func (_this *BeanTopo) GetDependencies(src string) []string {
+	var dependencies []string
+	for line := range _this.lineMap {
+		if line.src == src {
+			dependencies = append(dependencies, line.to)
+		}
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// GetReasons get the reasons of the dependency line
+func (_this *BeanTopo) GetReasons(src string, to string) []string {
+	return _this.reasons[DependencyChain{src: src, to: to}]
+}
+
 func (_this *BeanTopo) addLine(chain *DependencyChain) {
 	_this.total++
 	_this.lines[_this.total] = NewDependencyNode(chain, _this.head[chain.src])
@@ -98,7 +149,7 @@ func (_this *BeanTopo) GetTopoBeans() (sorted []string, err error) {
 		if len(_this.in) == 0 {
 			return nil, nil
 		} else {
-			return nil, &base.ErrIllegalState{ErrMsg: "Failed to initialize ALL beans,\n which may have formed circular dependencies"}
+			return nil, _this.circularDependencyError(_this.unresolved())
 		}
 	}
 	cnt := 0
@@ -117,11 +168,67 @@ func (_this *BeanTopo) GetTopoBeans() (sorted []string, err error) {
 		}
 	}
 	if (cnt ^ n) != 0 {
-		return nil, &base.ErrIllegalState{ErrMsg: " Failed to initialize beans,\n --> which may have formed circular dependencies"}
+		return nil, _this.circularDependencyError(_this.unresolved())
 	}
 	return sorted, nil
 }
 
+// unresolved the beans still depended on after GetTopoBeans
+func (_this *BeanTopo) unresolved() []string {
+	var unresolved []string
+	for s, cnt := range _this.in {
+		if cnt > 0 {
+			unresolved = append(unresolved, s)
+		}
+	}
+	return unresolved
+}
+
+// BuildLayers After Adds, get the layers of the beans, the dependencies come first, each layer is sorted by name.
+// the beans in a layer only depend on the beans in the previous layers, so they can be initialized concurrently.
+// it does not change the state used by Build. 获取分层的 topo 序列, 同一层的 bean 互不依赖
+func (_this *BeanTopo) BuildLayers() (layers [][]string, err error) {
+	out := make(map[string]int, len(_this.in)) // the count of the dependencies not initialized
+	dependents := make(map[string][]string)    // to -> src
+	for line := range _this.lineMap {
+		out[line.to] += 0
+		out[line.src]++
+		dependents[line.to] = append(dependents[line.to], line.src)
+	}
+	var layer []string
+	for s, cnt := range out {
+		if cnt == 0 {
+			layer = append(layer, s)
+		}
+	}
+	total := 0
+	for len(layer) > 0 {
+		sort.Strings(layer)
+		layers = append(layers, layer)
+		total += len(layer)
+		var nextLayer []string
+		for _, now := range layer {
+			for _, src := range dependents[now] {
+				out[src]--
+				if out[src] == 0 {
+					nextLayer = append(nextLayer, src)
+				}
+			}
+		}
+		layer = nextLayer
+	}
+	if total != len(out) {
+		var unresolved []string
+		for s, cnt := range out {
+			if cnt > 0 {
+				unresolved = append(unresolved, s)
+			}
+		}
+		return nil, _this.circularDependencyError(unresolved)
+	}
+	return layers, nil
+}
+
 // Build After Adds, Build the topo from step 2 and step 3
 func (_this *BeanTopo) Build() (sorted []string, err error) {
 	_this.GetLines()
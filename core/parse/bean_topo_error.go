@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"fmt"
+	"github.com/cutexingluo/go-spring/common/base"
+	"sort"
+	"strings"
+)
+
+// DependencyCycle a circular dependency path. 循环依赖路径
+type DependencyCycle struct {
+	Beans   []string   // the beans of the cycle, the first bean is repeated at the end, such as A -> B -> C -> A
+	Reasons [][]string // Reasons[i] is the reasons of the line Beans[i] -> Beans[i+1], such as the struct fields and tags
+}
+
+// String returns the path like A -> B -> C -> A
+func (_this *DependencyCycle) String() string {
+	return strings.Join(_this.Beans, " -> ")
+}
+
+// ErrCircularDependency the beans can not be sorted because of the circular dependencies. 循环依赖错误
+//
+// it unwraps to base.ErrIllegalState, which was returned before.
+type ErrCircularDependency struct {
+	error
+	Cycles     []DependencyCycle // each circular dependency path
+	Unresolved []string          // all beans left unresolved, with the beans depended on by the cycles (Build) or depending on them (BuildLayers)
+}
+
+func (e *ErrCircularDependency) Error() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("ErrCircularDependency : failed to initialize all beans, %d circular dependencies found:", len(e.Cycles)))
+	for i, cycle := range e.Cycles {
+		builder.WriteString(fmt.Sprintf("\n  [%d] %s", i+1, cycle.String()))
+		for j, reasons := range cycle.Reasons {
+			if len(reasons) == 0 {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("\n      %s -> %s : %s", cycle.Beans[j], cycle.Beans[j+1], strings.Join(reasons, "; ")))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("\n  unresolved beans: [%s]", strings.Join(e.Unresolved, ", ")))
+	return builder.String()
+}
+
+// Unwrap returns base.ErrIllegalState
+func (e *ErrCircularDependency) Unwrap() error {
+	return &base.ErrIllegalState{ErrMsg: "Failed to initialize ALL beans,\n which may have formed circular dependencies"}
+}
+
+// circularDependencyError find the cycles in the beans left unresolved by GetTopoBeans or BuildLayers
+func (_this *BeanTopo) circularDependencyError(unresolved []string) *ErrCircularDependency {
+	unresolvedSet := make(map[string]bool)
+	for _, s := range unresolved {
+		unresolvedSet[s] = true
+	}
+	sort.Strings(unresolved)
+
+	// the lines between the unresolved beans, sorted for a stable result
+	next := make(map[string][]string)
+	for line := range _this.lineMap {
+		if unresolvedSet[line.src] && unresolvedSet[line.to] {
+			next[line.src] = append(next[line.src], line.to)
+		}
+	}
+	for _, tos := range next {
+		sort.Strings(tos)
+	}
+
+	// dfs, every line back to the stack is a cycle
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int)
+	var stack []string
+	var cycles []DependencyCycle
+	found := make(map[string]bool)
+	var dfs func(now string)
+	dfs = func(now string) {
+		color[now] = gray
+		stack = append(stack, now)
+		for _, to := range next[now] {
+			switch color[to] {
+			case white:
+				dfs(to)
+			case gray:
+				start := len(stack) - 1
+				for stack[start] != to {
+					start--
+				}
+				if cycle, key := _this.newDependencyCycle(stack[start:]); !found[key] {
+					found[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[now] = black
+	}
+	for _, s := range unresolved {
+		if color[s] == white {
+			dfs(s)
+		}
+	}
+	return &ErrCircularDependency{Cycles: cycles, Unresolved: unresolved}
+}
+
+// CycleError create the ErrCircularDependency of the path found outside the topo sort, such as A, B for A -> B -> A
+func (_this *BeanTopo) CycleError(path []string) *ErrCircularDependency {
+	cycle, _ := _this.newDependencyCycle(path)
+	unresolved := append([]string(nil), path...)
+	sort.Strings(unresolved)
+	return &ErrCircularDependency{Cycles: []DependencyCycle{cycle}, Unresolved: unresolved}
+}
+
+// newDependencyCycle create the cycle from the path, rotated to start at the smallest bean, key is used to remove duplicates
+func (_this *BeanTopo) newDependencyCycle(path []string) (cycle DependencyCycle, key string) {
+	minIndex := 0
+	for i, s := range path {
+		if s < path[minIndex] {
+			minIndex = i
+		}
+	}
+	beans := make([]string, 0, len(path)+1)
+	beans = append(beans, path[minIndex:]...)
+	beans = append(beans, path[:minIndex]...)
+	beans = append(beans, beans[0])
+	reasons := make([][]string, len(beans)-1)
+	for i := range reasons {
+		reasons[i] = _this.GetReasons(beans[i], beans[i+1])
+	}
+	cycle = DependencyCycle{Beans: beans, Reasons: reasons}
+	return cycle, cycle.String()
+}
@@ -0,0 +1,78 @@
+package parse_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/common/base"
+	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCircularDependencyError two cycles with the reasons, and the beans around a cycle are unresolved
+func TestCircularDependencyError(t *testing.T) {
+	topo := parse.NewBeanTopo()
+	topo.AddWithReason("b", "c", "field C `bean:\"c\"`")
+	topo.AddWithReason("c", "b", "field B `bean:\"b\"`")
+	topo.AddWithReason("c", "b", "field B2 `bean:\"b\"`")
+	topo.Add("x", "y")
+	topo.Add("y", "z")
+	topo.Add("z", "x")
+	topo.Add("a", "b") // depends on a cycle
+	topo.Add("b", "d") // depended on by a cycle, resolved
+	// Build sorts from the dependents, so the beans depended on are left, BuildLayers is the opposite
+	tests := []struct {
+		name       string
+		build      func() error
+		unresolved string
+	}{
+		{"Build", func() error { _, err := topo.Build(); return err }, "b, c, d, x, y, z"},
+		{"BuildLayers", func() error { _, err := topo.BuildLayers(); return err }, "a, b, c, x, y, z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.build()
+			var cycleErr *parse.ErrCircularDependency
+			if !errors.As(err, &cycleErr) {
+				t.Fatalf("got the error %v, want ErrCircularDependency", err)
+			}
+			want := []parse.DependencyCycle{
+				{Beans: []string{"b", "c", "b"}, Reasons: [][]string{
+					{"field C `bean:\"c\"`"}, {"field B `bean:\"b\"`", "field B2 `bean:\"b\"`"},
+				}},
+				{Beans: []string{"x", "y", "z", "x"}, Reasons: [][]string{nil, nil, nil}},
+			}
+			if !reflect.DeepEqual(cycleErr.Cycles, want) {
+				t.Errorf("got the cycles %+v, want %+v", cycleErr.Cycles, want)
+			}
+			if got := strings.Join(cycleErr.Unresolved, ", "); got != test.unresolved {
+				t.Errorf("got the unresolved beans %s, want %s", got, test.unresolved)
+			}
+			var illegalState *base.ErrIllegalState
+			if !errors.As(err, &illegalState) {
+				t.Errorf("the error %v does not unwrap to ErrIllegalState", err)
+			}
+			for _, text := range []string{"2 circular dependencies", "[1] b -> c -> b", "[2] x -> y -> z -> x",
+				"c -> b : field B `bean:\"b\"`; field B2 `bean:\"b\"`", "unresolved beans: [" + test.unresolved + "]"} {
+				if !strings.Contains(err.Error(), text) {
+					t.Errorf("the error %q does not contain %q", err.Error(), text)
+				}
+			}
+		})
+	}
+}
+
+// TestCycleError the path is rotated to start at the smallest bean
+func TestCycleError(t *testing.T) {
+	topo := parse.NewBeanTopo()
+	topo.AddWithReason("b", "a", "field A")
+	topo.AddWithReason("a", "b", "field B")
+	err := topo.CycleError([]string{"b", "a"})
+	want := parse.DependencyCycle{Beans: []string{"a", "b", "a"}, Reasons: [][]string{{"field B"}, {"field A"}}}
+	if len(err.Cycles) != 1 || !reflect.DeepEqual(err.Cycles[0], want) {
+		t.Errorf("got the cycles %+v, want %+v", err.Cycles, want)
+	}
+	if !reflect.DeepEqual(err.Unresolved, []string{"a", "b"}) {
+		t.Errorf("got the unresolved beans %v", err.Unresolved)
+	}
+}
@@ -0,0 +1,67 @@
+package parse_test
+
+import (
+	"errors"
+	"github.com/cutexingluo/go-spring/core/parse"
+	"reflect"
+	"testing"
+)
+
+// TestBuildLayers each layer only depends on the previous layers, and is sorted by name
+func TestBuildLayers(t *testing.T) {
+	topo := parse.NewBeanTopo()
+	topo.Add("a", "c")
+	topo.Add("a", "b")
+	topo.Add("b", "d")
+	topo.Add("c", "d")
+	topo.Add("e", "d")
+	topo.Add("f", "a")
+	layers, err := topo.BuildLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"d"}, {"b", "c", "e"}, {"a"}, {"f"}}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("got the layers %v, want %v", layers, want)
+	}
+
+	// BuildLayers does not change the state of Build
+	sorted, err := topo.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := make(map[string]int, len(sorted))
+	for i, bean := range sorted {
+		index[bean] = i
+	}
+	for _, edge := range topo.GetEdges() {
+		if index[edge.Src] > index[edge.To] {
+			t.Errorf("Build got %v, '%s' is after its dependency '%s'", sorted, edge.Src, edge.To)
+		}
+	}
+	if again, _ := topo.BuildLayers(); !reflect.DeepEqual(again, want) {
+		t.Errorf("got the layers %v after Build, want %v", again, want)
+	}
+}
+
+func TestBuildLayersEmpty(t *testing.T) {
+	if layers, err := parse.NewBeanTopo().BuildLayers(); err != nil || len(layers) != 0 {
+		t.Errorf("got the layers %v and the error %v of the empty topo", layers, err)
+	}
+}
+
+func TestBuildLayersCycle(t *testing.T) {
+	topo := parse.NewBeanTopo()
+	topo.Add("a", "b")
+	topo.Add("b", "a")
+	topo.Add("c", "a")
+	topo.Add("b", "d")
+	_, err := topo.BuildLayers()
+	var cycleErr *parse.ErrCircularDependency
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("got the error %v, want ErrCircularDependency", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(cycleErr.Unresolved, want) {
+		t.Errorf("got the unresolved beans %v, want %v", cycleErr.Unresolved, want)
+	}
+}
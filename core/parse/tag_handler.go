@@ -77,13 +77,15 @@ func InitPtrByTag(src *reflect.Value, handler TagHandler) (dst *reflect.Value, e
 	}
 	ret := src           // 新建对象, 返回指针
 	dstVal := src.Elem() // 新建对象, 返回结构体
-	// 设置 值
+	// 设置 值, 返回第一个错误
 	FieldHandler(&dstVal, func(srcField *reflect.Value, fieldStruct *reflect.StructField) {
+		if err != nil {
+			return
+		}
 		kind := srcField.Kind()
 		err = handler.Parse(&dstVal, &kind, srcField, fieldStruct)
-		return
 	})
-	return ret, nil
+	return ret, err
 }
 
 // InitStructByTag - 根据 tag 初始化结构体, 返回新的对象
@@ -93,13 +95,15 @@ func InitStructByTag(src *reflect.Value, handler TagHandler) (dst *reflect.Value
 	}
 	ret := reflect.New(src.Type()) // 新建对象, 返回指针
 	dstVal := ret.Elem()           // 新建对象, 返回结构体
-	// 设置 值
+	// 设置 值, 返回第一个错误
 	FieldHandler(&dstVal, func(srcField *reflect.Value, fieldStruct *reflect.StructField) {
+		if err != nil {
+			return
+		}
 		kind := srcField.Kind()
 		err = handler.Parse(&dstVal, &kind, srcField, fieldStruct)
-		return
 	})
-	return &ret, nil
+	return &ret, err
 }
 
 // 		kind := srcField.Kind()